@@ -3,7 +3,9 @@ package components
 import (
 	"embed"
 	"html/template"
+	"io/fs"
 
+	"github.com/blakewilliams/amaro/httprouter"
 	"github.com/blakewilliams/glam"
 )
 
@@ -13,11 +15,33 @@ var requestFuncMap = glam.FuncMap{
 	"CSRFInput": func() template.HTML {
 		panic("pass in render")
 	},
+	"CSPNonce": func() string {
+		panic("pass in render")
+	},
 }
 
 //go:embed all:templates/*
 var templateFS embed.FS
 
+//go:embed all:assets
+var assetsFS embed.FS
+
+// NewAssets returns the fingerprinted static assets in the assets directory,
+// served at /assets. Use the AssetPath template function to reference them.
+func NewAssets() *httprouter.Assets {
+	sub, err := fs.Sub(assetsFS, "assets")
+	if err != nil {
+		panic(err)
+	}
+
+	assets, err := httprouter.NewAssets(sub, httprouter.AssetsConfig{})
+	if err != nil {
+		panic(err)
+	}
+
+	return assets
+}
+
 func New(funcMap glam.FuncMap) *glam.Engine {
 	funcs := make(glam.FuncMap, len(requestFuncMap)+len(funcMap))
 	for k, v := range requestFuncMap {
@@ -9,6 +9,7 @@ import (
 	"github.com/blakewilliams/amaro/_template/internal/core"
 	"github.com/blakewilliams/amaro/_template/internal/web/components"
 	"github.com/blakewilliams/amaro/httprouter"
+	"github.com/blakewilliams/amaro/httprouter/middleware"
 	"github.com/blakewilliams/glam"
 )
 
@@ -30,6 +31,7 @@ func newRequestContext(s *Server) func(r httprouter.RequestContext) *requestCont
 		return &requestContext{
 			renderer:       s.renderer,
 			RequestContext: r,
+			Environment:    s.app.Environment,
 		}
 	}
 }
@@ -38,6 +40,9 @@ func newRequestContext(s *Server) func(r httprouter.RequestContext) *requestCont
 // io.Writer.
 func (rc *requestContext) RenderTo(ctx context.Context, w io.Writer, component any) {
 	err := rc.renderer.RenderWithFuncs(w, component, glam.FuncMap{
+		"CSPNonce": func() string {
+			return middleware.GetCSPNonce(rc)
+		},
 		// "CSRFToken": func() string {
 		// 	return rc.SessionData().CSRF.AuthenticityToken()
 		// },
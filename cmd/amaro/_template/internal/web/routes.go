@@ -1,5 +1,8 @@
 package web
 
+import "github.com/blakewilliams/amaro/httprouter"
+
 func (s *Server) registerRoutes() {
+	httprouter.Static(s.router, s.assets)
 	s.router.Get("/", homeHandler)
 }
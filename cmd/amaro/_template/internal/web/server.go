@@ -23,13 +23,17 @@ type Server struct {
 	router       *httprouter.Router[*requestContext]
 	app          *core.Application
 	renderer     *glam.Engine
+	assets       *httprouter.Assets
 	sessionStore session.Store[*sessionData]
 }
 
 func NewServer(app *core.Application) *Server {
 	s := &Server{app: app}
 	s.sessionStore = initSessionStore(s)
-	s.renderer = components.New(nil)
+	s.assets = components.NewAssets()
+	s.renderer = components.New(glam.FuncMap{
+		"AssetPath": s.assets.AssetPath,
+	})
 	s.router = initRouter(s)
 
 	/// see web/routes.go
@@ -41,7 +45,24 @@ func NewServer(app *core.Application) *Server {
 func initRouter(s *Server) *httprouter.Router[*requestContext] {
 	r := httprouter.New[*requestContext](newRequestContext(s))
 	r.UseMetal(metal.MethodRewrite)
+	r.Use(middleware.RequestID[*requestContext]())
+	r.Use(middleware.Logger[*requestContext](s.app.Logger))
 	r.Use(middleware.ErrorHandler(s.app.Logger, errorHandler))
+	r.Use(middleware.DevErrors(middleware.DevErrorsConfig[*requestContext]{
+		Environment: string(s.app.Environment),
+		Logger:      s.app.Logger,
+		Session: func(rc *requestContext) any {
+			return rc.SessionData()
+		},
+	}))
+	r.Use(middleware.SecurityHeaders[*requestContext](middleware.SecurityHeadersConfig{
+		ContentSecurityPolicy: middleware.NewCSP().
+			DefaultSrc(middleware.CSPSelf).
+			ScriptSrc(middleware.CSPSelf, middleware.CSPNonce).
+			StyleSrc(middleware.CSPSelf, middleware.CSPNonce).
+			ObjectSrc(middleware.CSPNone).
+			BaseURI(middleware.CSPSelf),
+	}))
 	r.Use(session.Middleware[*requestContext, *sessionData](s.sessionStore))
 
 	return r
@@ -65,12 +86,11 @@ func initSessionStore(s *Server) session.Store[*sessionData] {
 	})
 }
 
+// errorHandler renders panics that aren't handled by middleware.DevErrors,
+// which is only enabled in development.
 func errorHandler(ctx context.Context, rc *requestContext, r any) {
-	if rc.Environment == "prod" {
-		rc.Response().WriteHeader(http.StatusInternalServerError)
-		rc.Render(ctx, components.Err500{Environment: rc.Environment, Error: r})
-		return
-	}
+	rc.Response().WriteHeader(http.StatusInternalServerError)
+	rc.Render(ctx, &components.Err500{Environment: rc.Environment, Error: r})
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,59 @@
+package httprouter
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// allowHeader returns the value of the `Allow` header for the given escaped
+// path, listing the methods of the routes matching it in the order they were
+// first registered. An empty string is returned when no route matches.
+func (r *Router[T]) allowHeader(path string, params []string) string {
+	paths := []string{path}
+	if r.options.trailingSlash != PathStrict && path != "/" {
+		paths = append(paths, toggleTrailingSlash(path))
+	}
+
+	var allowed []string
+	for _, method := range r.methods {
+		if method == http.MethodOptions {
+			continue
+		}
+
+		for _, p := range paths {
+			if _, _, ok := r.find(method, p, params[:0]); ok {
+				allowed = append(allowed, method)
+				break
+			}
+		}
+	}
+
+	if len(allowed) == 0 {
+		return ""
+	}
+
+	return strings.Join(append(allowed, http.MethodOptions), ", ")
+}
+
+// preflightRoute returns the route matching the method requested by a CORS
+// preflight request in its `Access-Control-Request-Method` header.
+func (r *Router[T]) preflightRoute(req *http.Request, path string, params []string) (*route[T], []string, bool) {
+	method := req.Header.Get("Access-Control-Request-Method")
+	if method == "" || method == http.MethodOptions {
+		return nil, params, false
+	}
+
+	route, params, ok := r.find(method, path, params)
+	if !ok && r.options.trailingSlash != PathStrict && path != "/" {
+		route, params, ok = r.find(method, toggleTrailingSlash(path), params[:0])
+	}
+
+	return route, params, ok
+}
+
+// autoOptionsHandler responds to OPTIONS requests for paths without an
+// OPTIONS route. The `Allow` header is set before it's called.
+func autoOptionsHandler[T RequestContext](ctx context.Context, rctx T) {
+	rctx.Response().WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,232 @@
+package httprouter
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// immutableCacheControl is used for fingerprinted assets, which never change.
+const immutableCacheControl = "public, max-age=31536000, immutable"
+
+type (
+	// AssetsConfig configures the assets returned by NewAssets.
+	AssetsConfig struct {
+		// Prefix is the URL path the assets are served from. Defaults to
+		// `/assets`.
+		Prefix string
+	}
+
+	// Assets serves the files of an fs.FS, like an embed.FS, using
+	// fingerprinted filenames that include a hash of their contents, e.g.
+	// `app-1a2b3c4d5e6f7a8b.css`. Fingerprinted files are served with
+	// immutable cache headers since their name changes when their contents
+	// do. Register the routes with Static and use AssetPath in templates.
+	Assets struct {
+		fsys   fs.FS
+		prefix string
+		// paths maps asset names to their fingerprinted names
+		paths map[string]string
+		// files maps fingerprinted names to the asset
+		files map[string]*asset
+		// names maps asset names to the asset
+		names map[string]*asset
+	}
+
+	asset struct {
+		name string
+		etag string
+		// encodings maps a Content-Encoding to the name of the
+		// precompressed file, e.g. `gzip` to `app.css.gz`
+		encodings map[string]string
+	}
+)
+
+// precompressedExtensions maps the extension of precompressed files to their
+// Content-Encoding, in order of preference.
+var precompressedExtensions = []struct {
+	ext      string
+	encoding string
+}{
+	{".br", "br"},
+	{".gz", "gzip"},
+}
+
+// NewAssets hashes the files in fsys so they can be served by Static. Files
+// ending in `.gz` or `.br` are served in place of the file with the same name
+// without the extension, e.g. `app.css.gz` for `app.css`, when the client
+// accepts the encoding.
+func NewAssets(fsys fs.FS, config AssetsConfig) (*Assets, error) {
+	if config.Prefix == "" {
+		config.Prefix = "/assets"
+	}
+
+	a := &Assets{
+		fsys:   fsys,
+		prefix: strings.TrimSuffix(config.Prefix, "/"),
+		paths:  make(map[string]string),
+		files:  make(map[string]*asset),
+		names:  make(map[string]*asset),
+	}
+
+	var precompressed []string
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		for _, pc := range precompressedExtensions {
+			if strings.HasSuffix(name, pc.ext) {
+				precompressed = append(precompressed, name)
+				return nil
+			}
+		}
+
+		contents, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(contents)
+		hash := hex.EncodeToString(sum[:8])
+		ext := path.Ext(name)
+		fingerprinted := strings.TrimSuffix(name, ext) + "-" + hash + ext
+
+		file := &asset{name: name, etag: `"` + hash + `"`, encodings: make(map[string]string)}
+		a.paths[name] = fingerprinted
+		a.files[fingerprinted] = file
+		a.names[name] = file
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load assets: %w", err)
+	}
+
+	for _, name := range precompressed {
+		for _, pc := range precompressedExtensions {
+			if file, ok := a.names[strings.TrimSuffix(name, pc.ext)]; ok && strings.HasSuffix(name, pc.ext) {
+				file.encodings[pc.encoding] = name
+			}
+		}
+	}
+
+	return a, nil
+}
+
+// AssetPath returns the URL path of the fingerprinted asset, e.g.
+// `AssetPath("app.css")` returns `/assets/app-1a2b3c4d5e6f7a8b.css`. Unknown
+// assets are returned without a fingerprint.
+func (a *Assets) AssetPath(name string) string {
+	name = strings.TrimPrefix(name, "/")
+	if fingerprinted, ok := a.paths[name]; ok {
+		return a.prefix + "/" + fingerprinted
+	}
+
+	return a.prefix + "/" + name
+}
+
+// Static registers GET and HEAD routes serving the assets under their prefix.
+// The assets can also be requested without a fingerprint, in which case they
+// must be revalidated by clients. Conditional and Range requests are handled
+// by http.ServeContent.
+//
+// Group prefixes are prepended to the routes, so use a Group without a prefix
+// to add middleware that only runs for assets.
+func Static[T RequestContext](r Registerable[T], assets *Assets, middleware ...Middleware[T]) {
+	handler := func(ctx context.Context, rc T) {
+		assets.serve(ctx, rc)
+	}
+
+	r.RawMatch(http.MethodGet, assets.prefix+"/*path", handler, middleware...)
+	r.RawMatch(http.MethodHead, assets.prefix+"/*path", handler, middleware...)
+}
+
+func (a *Assets) serve(ctx context.Context, rc RequestContext) {
+	name := rc.Params()["path"]
+	header := rc.Response().Header()
+
+	file, ok := a.files[name]
+	if ok {
+		header.Set("Cache-Control", immutableCacheControl)
+	} else if file, ok = a.names[name]; ok {
+		header.Set("Cache-Control", "public, max-age=0, must-revalidate")
+	} else {
+		ReportError(ctx, rc, NewHTTPError(http.StatusNotFound, "", nil))
+		return
+	}
+
+	if len(file.encodings) > 0 {
+		header.Add("Vary", "Accept-Encoding")
+	}
+
+	// Each encoding is a different representation, so it needs its own ETag
+	served := file.name
+	etag := file.etag
+	acceptEncoding := rc.Request().Header.Get("Accept-Encoding")
+	for _, pc := range precompressedExtensions {
+		if precompressed, ok := file.encodings[pc.encoding]; ok && acceptsEncoding(acceptEncoding, pc.encoding) {
+			served = precompressed
+			etag = strings.TrimSuffix(file.etag, `"`) + "-" + pc.encoding + `"`
+			header.Set("Content-Encoding", pc.encoding)
+			break
+		}
+	}
+
+	header.Set("ETag", etag)
+	if contentType := mime.TypeByExtension(path.Ext(file.name)); contentType != "" {
+		header.Set("Content-Type", contentType)
+	} else if served != file.name {
+		// ServeContent would sniff the compressed contents
+		header.Set("Content-Type", "application/octet-stream")
+	}
+
+	f, err := a.fsys.Open(served)
+	if err != nil {
+		ReportError(ctx, rc, err)
+		return
+	}
+	defer f.Close()
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		contents, err := io.ReadAll(f)
+		if err != nil {
+			ReportError(ctx, rc, err)
+			return
+		}
+
+		content = bytes.NewReader(contents)
+	}
+
+	// ServeContent handles If-None-Match using the ETag header and Range
+	// requests. The modification time is omitted since it's not stable
+	// across builds, e.g. it's always zero for embed.FS.
+	http.ServeContent(rc.Response(), rc.Request(), file.name, time.Time{}, content)
+}
+
+// acceptsEncoding reports whether the Accept-Encoding header allows the given
+// encoding.
+func acceptsEncoding(acceptEncoding string, encoding string) bool {
+	for _, value := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(value), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+
+		q := strings.ReplaceAll(params, " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+
+	return false
+}
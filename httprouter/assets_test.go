@@ -0,0 +1,163 @@
+package httprouter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAssets(t *testing.T) *Assets {
+	assets, err := NewAssets(fstest.MapFS{
+		"app.css":       {Data: []byte("body { color: red; }")},
+		"app.css.gz":    {Data: []byte("gzipped css")},
+		"app.css.br":    {Data: []byte("brotli css")},
+		"js/app.min.js": {Data: []byte("console.log('hi')")},
+	}, AssetsConfig{})
+	require.NoError(t, err)
+
+	return assets
+}
+
+func TestAssets_AssetPath(t *testing.T) {
+	assets := newTestAssets(t)
+
+	require.Regexp(t, regexp.MustCompile(`^/assets/app-[0-9a-f]{16}\.css$`), assets.AssetPath("app.css"))
+	require.Regexp(t, regexp.MustCompile(`^/assets/js/app\.min-[0-9a-f]{16}\.js$`), assets.AssetPath("/js/app.min.js"))
+	require.Equal(t, "/assets/missing.css", assets.AssetPath("missing.css"))
+
+	assets, err := NewAssets(fstest.MapFS{"app.css": {Data: []byte("body { color: red; }")}}, AssetsConfig{Prefix: "/static/"})
+	require.NoError(t, err)
+	require.Regexp(t, regexp.MustCompile(`^/static/app-[0-9a-f]{16}\.css$`), assets.AssetPath("app.css"))
+}
+
+func TestStatic(t *testing.T) {
+	assets := newTestAssets(t)
+	router := New(func(r RequestContext) RequestContext {
+		return r
+	})
+	Static(router, assets)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, assets.AssetPath("js/app.min.js"), nil))
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "console.log('hi')", res.Body.String())
+	require.Equal(t, "text/javascript; charset=utf-8", res.Header().Get("Content-Type"))
+	require.Equal(t, "public, max-age=31536000, immutable", res.Header().Get("Cache-Control"))
+	require.Empty(t, res.Header().Get("Vary"))
+
+	// Unfingerprinted paths must be revalidated
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/assets/app.css", nil))
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "body { color: red; }", res.Body.String())
+	require.Equal(t, "public, max-age=0, must-revalidate", res.Header().Get("Cache-Control"))
+	require.Equal(t, "Accept-Encoding", res.Header().Get("Vary"))
+
+	etag := res.Header().Get("ETag")
+	req := httptest.NewRequest(http.MethodGet, "/assets/app.css", nil)
+	req.Header.Set("If-None-Match", etag)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusNotModified, res.Code)
+	require.Empty(t, res.Body.String())
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodHead, assets.AssetPath("app.css"), nil))
+	require.Equal(t, http.StatusOK, res.Code)
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/assets/app.css.gz", nil))
+	require.Equal(t, http.StatusNotFound, res.Code)
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/assets/missing.css", nil))
+	require.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestStatic_Precompressed(t *testing.T) {
+	assets := newTestAssets(t)
+	router := New(func(r RequestContext) RequestContext {
+		return r
+	})
+
+	var called bool
+	Static(router.Group(""), assets, func(ctx context.Context, r RequestContext, next Handler[RequestContext]) {
+		called = true
+		next(ctx, r)
+	})
+
+	tests := []struct {
+		acceptEncoding string
+		encoding       string
+		body           string
+	}{
+		{"gzip, deflate, br", "br", "brotli css"},
+		{"gzip, br;q=0", "gzip", "gzipped css"},
+		{"deflate", "", "body { color: red; }"},
+	}
+
+	etags := map[string]bool{}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, assets.AssetPath("app.css"), nil)
+		req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+
+		require.Equal(t, http.StatusOK, res.Code)
+		require.Equal(t, tc.encoding, res.Header().Get("Content-Encoding"))
+		require.Equal(t, tc.body, res.Body.String())
+		require.Equal(t, "text/css; charset=utf-8", res.Header().Get("Content-Type"))
+		etags[res.Header().Get("ETag")] = true
+	}
+
+	require.Len(t, etags, 3)
+	require.True(t, called)
+}
+
+func TestStatic_Conditional(t *testing.T) {
+	assets := newTestAssets(t)
+	router := New(func(r RequestContext) RequestContext {
+		return r
+	})
+	Static(router, assets)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, assets.AssetPath("app.css"), nil))
+	etag := res.Header().Get("ETag")
+
+	tests := map[string]struct {
+		ifNoneMatch string
+		status      int
+	}{
+		"any":            {ifNoneMatch: "*", status: http.StatusNotModified},
+		"list":           {ifNoneMatch: `"other", ` + etag, status: http.StatusNotModified},
+		"weak":           {ifNoneMatch: "W/" + etag, status: http.StatusNotModified},
+		"substring":      {ifNoneMatch: `"x` + etag[1:], status: http.StatusOK},
+		"other encoding": {ifNoneMatch: etag[:len(etag)-1] + `-gzip"`, status: http.StatusOK},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, assets.AssetPath("app.css"), nil)
+			req.Header.Set("If-None-Match", tc.ifNoneMatch)
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, tc.status, res.Code)
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, assets.AssetPath("app.css"), nil)
+	req.Header.Set("Range", "bytes=0-3")
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusPartialContent, res.Code)
+	require.Equal(t, "body", res.Body.String())
+	require.Equal(t, "bytes 0-3/20", res.Header().Get("Content-Range"))
+}
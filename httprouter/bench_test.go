@@ -0,0 +1,258 @@
+package httprouter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// benchRoutes is a small API shaped route table used by the benchmarks.
+var benchRoutes = []struct {
+	method string
+	path   string
+}{
+	{http.MethodGet, "/"},
+	{http.MethodGet, "/about"},
+	{http.MethodGet, "/users"},
+	{http.MethodPost, "/users"},
+	{http.MethodGet, "/users/:id"},
+	{http.MethodPatch, "/users/:id"},
+	{http.MethodDelete, "/users/:id"},
+	{http.MethodGet, "/users/:id/repos"},
+	{http.MethodGet, "/repos/:owner/:repo"},
+	{http.MethodGet, "/repos/:owner/:repo/issues"},
+	{http.MethodGet, "/repos/:owner/:repo/issues/:number"},
+	{http.MethodGet, "/repos/:owner/:repo/issues/:number/comments"},
+	{http.MethodGet, "/repos/:owner/:repo/pulls"},
+	{http.MethodGet, "/repos/:owner/:repo/pulls/:number"},
+	{http.MethodGet, "/api/v1/status"},
+	{http.MethodGet, "/api/v1/health/live"},
+	{http.MethodGet, "/api/v1/health/ready"},
+	{http.MethodGet, "/assets/*path"},
+}
+
+var benchRequests = []struct {
+	name string
+	path string
+}{
+	{"Static", "/about"},
+	{"StaticDeep", "/api/v1/health/ready"},
+	{"Param", "/users/42"},
+	{"MultiParam", "/repos/blakewilliams/amaro/issues/42/comments"},
+	{"Wildcard", "/assets/css/app.css"},
+	{"NotFound", "/missing/route"},
+}
+
+func BenchmarkRouter(b *testing.B) {
+	handler := func(ctx context.Context, r *rootRequestContext) {}
+
+	router := New(WithBasicRequestContext)
+	legacy := newLegacyRouter()
+	for _, route := range benchRoutes {
+		router.Match(route.method, route.path, handler)
+		legacy.Match(route.method, route.path, handler)
+	}
+
+	for _, tc := range benchRequests {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		rw := &discardResponseWriter{header: make(http.Header)}
+
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				router.ServeHTTP(rw, req)
+			}
+		})
+
+		b.Run(tc.name+"Legacy", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				legacy.ServeHTTP(rw, req)
+			}
+		})
+	}
+}
+
+func BenchmarkRouter_Parallel(b *testing.B) {
+	router := New(WithBasicRequestContext)
+	for _, route := range benchRoutes {
+		router.Match(route.method, route.path, func(ctx context.Context, r *rootRequestContext) {})
+	}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		req := httptest.NewRequest(http.MethodGet, "/repos/blakewilliams/amaro/pulls/7", nil)
+		rw := &discardResponseWriter{header: make(http.Header)}
+
+		for pb.Next() {
+			router.ServeHTTP(rw, req)
+		}
+	})
+}
+
+func TestRouter_ZeroAllocLookup(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector adds allocations")
+	}
+
+	router := New(WithBasicRequestContext)
+	for _, route := range benchRoutes {
+		router.Match(route.method, route.path, func(ctx context.Context, r *rootRequestContext) {})
+	}
+
+	for _, tc := range benchRequests {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		rw := &discardResponseWriter{header: make(http.Header)}
+
+		// Warm the pool so the RequestContext is reused.
+		router.ServeHTTP(rw, req)
+
+		allocs := testing.AllocsPerRun(100, func() {
+			router.ServeHTTP(rw, req)
+		})
+
+		if allocs != 0 {
+			t.Errorf("expected %s to not allocate, got %v allocations", tc.name, allocs)
+		}
+	}
+}
+
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (d *discardResponseWriter) Header() http.Header         { return d.header }
+func (d *discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (d *discardResponseWriter) WriteHeader(int)             {}
+
+// legacyRouter reproduces the request handling of the router before lookups
+// captured params during the tree walk. It exists so the benchmarks can be
+// compared against the previous implementation.
+type legacyRouter struct {
+	tree *legacyNode
+}
+
+type legacyRoute struct {
+	method  string
+	path    string
+	parts   []string
+	handler Handler[*rootRequestContext]
+}
+
+type legacyNode struct {
+	value    *legacyRoute
+	isSet    bool
+	children map[string]*legacyNode
+}
+
+func newLegacyRouter() *legacyRouter {
+	return &legacyRouter{tree: &legacyNode{children: make(map[string]*legacyNode)}}
+}
+
+func (l *legacyRouter) Match(method string, path string, handler Handler[*rootRequestContext]) {
+	route := &legacyRoute{method: method, path: path, parts: normalizeRoutePath(path), handler: handler}
+
+	current := l.tree
+	for _, segment := range append([]string{method}, route.parts...) {
+		key := segment
+		if strings.HasPrefix(segment, ":") {
+			key = ":named"
+		} else if strings.HasPrefix(segment, "*") {
+			key = "*"
+		}
+
+		child, ok := current.children[key]
+		if !ok {
+			child = &legacyNode{children: make(map[string]*legacyNode)}
+			current.children[key] = child
+		}
+		current = child
+	}
+
+	current.value = route
+	current.isSet = true
+}
+
+func (l *legacyRouter) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	httpHandler := func(rw http.ResponseWriter, req *http.Request) {
+		lookup := []string{req.Method}
+		lookup = append(lookup, normalizeRoutePath(req.URL.Path)...)
+
+		var handler Handler[*rootRequestContext]
+		var params map[string]string
+		var path string
+
+		ok, value := l.tree.lookup(lookup)
+		if ok {
+			handler = value.handler
+			path = value.path
+			params = value.match(req)
+		} else {
+			params = map[string]string{}
+			handler = func(ctx context.Context, rctx *rootRequestContext) {
+				rctx.Response().WriteHeader(http.StatusNotFound)
+			}
+		}
+
+		reqCtx := NewRequestContext(req, rw, path, params)
+		handler(req.Context(), reqCtx)
+		_, _ = reqCtx.Response().Flush()
+	}
+
+	httpHandler(rw, req)
+}
+
+func (n *legacyNode) lookup(segments []string) (bool, *legacyRoute) {
+	current := n
+	var lastWildcard *legacyNode
+
+	for _, segment := range segments {
+		if wildcard, ok := current.children["*"]; ok {
+			lastWildcard = wildcard
+		}
+
+		if child, ok := current.children[segment]; ok {
+			current = child
+			continue
+		}
+
+		child, ok := current.children[":named"]
+		if !ok {
+			if lastWildcard != nil {
+				return true, lastWildcard.value
+			}
+
+			return false, reflect.Zero(reflect.TypeOf(n.value)).Interface().(*legacyRoute)
+		}
+
+		current = child
+	}
+
+	if current.isSet {
+		return true, current.value
+	}
+
+	if lastWildcard != nil {
+		return true, lastWildcard.value
+	}
+
+	return false, current.value
+}
+
+func (r *legacyRoute) match(req *http.Request) map[string]string {
+	reqParts := normalizeRoutePath(req.URL.Path)
+	params := make(map[string]string)
+
+	for i, part := range r.parts {
+		if strings.HasPrefix(part, ":") {
+			params[part[1:]] = reqParts[i]
+		} else if strings.HasPrefix(part, "*") {
+			params[part[1:]] = strings.Join(reqParts[i:], "/")
+		}
+	}
+
+	return params
+}
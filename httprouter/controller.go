@@ -29,12 +29,12 @@ type (
 
 	// ControllerRoutable ensures consistency across all controller based types.
 	ControllerRoutable[T RequestContext, RequestData FromRequest[T]] interface {
-		Match(string, string, ControllerHandler[T, RequestData])
-		Get(string, ControllerHandler[T, RequestData])
-		Post(string, ControllerHandler[T, RequestData])
-		Put(string, ControllerHandler[T, RequestData])
-		Patch(string, ControllerHandler[T, RequestData])
-		Delete(string, ControllerHandler[T, RequestData])
+		Match(string, string, ControllerHandler[T, RequestData], ...Middleware[T])
+		Get(string, ControllerHandler[T, RequestData], ...Middleware[T])
+		Post(string, ControllerHandler[T, RequestData], ...Middleware[T])
+		Put(string, ControllerHandler[T, RequestData], ...Middleware[T])
+		Patch(string, ControllerHandler[T, RequestData], ...Middleware[T])
+		Delete(string, ControllerHandler[T, RequestData], ...Middleware[T])
 		Use(func(context.Context, T, Handler[T]))
 	}
 
@@ -56,7 +56,7 @@ func NewController[Parent RequestContext, RequestData FromRequest[Parent]](r Reg
 		root: &controllerGroup[Parent, RequestData]{
 			prefix:      "",
 			parent:      r,
-			middlewares: make([]func(context.Context, Parent, Handler[Parent]), 0),
+			middlewares: make([]Middleware[Parent], 0),
 		},
 	}
 }
@@ -64,38 +64,45 @@ func NewController[Parent RequestContext, RequestData FromRequest[Parent]](r Reg
 // RawMatch implements the Registerable interface and forwards the call to the
 // parent router. This allows controllers and groups to be registered with the
 // current controller.
-func (r *Controller[T, RequestData]) RawMatch(method string, path string, fn Handler[T]) {
-	r.parent.RawMatch(method, path, fn)
+func (r *Controller[T, RequestData]) RawMatch(method string, path string, fn Handler[T], middleware ...Middleware[T]) {
+	r.parent.RawMatch(method, path, fn, middleware...)
 }
 
-// Match registers the given handler with the given method and path.
-func (r *Controller[T, RequestData]) Match(method string, path string, fn ControllerHandler[T, RequestData]) {
-	r.root.Match(method, path, fn)
+// rawMatchStack implements lazyRegisterable and forwards the call to the parent
+// router.
+func (r *Controller[T, RequestData]) rawMatchStack(method string, path string, fn Handler[T], stack func() []Middleware[T]) {
+	registerStack(r.parent, method, path, fn, stack)
+}
+
+// Match registers the given handler with the given method and path. The
+// middleware passed only run for this route, before FromRequest is called.
+func (r *Controller[T, RequestData]) Match(method string, path string, fn ControllerHandler[T, RequestData], middleware ...Middleware[T]) {
+	r.root.Match(method, path, fn, middleware...)
 }
 
 // Get registers a GET handler with the given path.
-func (r *Controller[T, RequestData]) Get(path string, fn ControllerHandler[T, RequestData]) {
-	r.root.Get(path, fn)
+func (r *Controller[T, RequestData]) Get(path string, fn ControllerHandler[T, RequestData], middleware ...Middleware[T]) {
+	r.root.Get(path, fn, middleware...)
 }
 
 // Post registers a POST handler with the given path.
-func (r *Controller[T, RequestData]) Post(path string, fn ControllerHandler[T, RequestData]) {
-	r.root.Post(path, fn)
+func (r *Controller[T, RequestData]) Post(path string, fn ControllerHandler[T, RequestData], middleware ...Middleware[T]) {
+	r.root.Post(path, fn, middleware...)
 }
 
 // Put registers a PUT handler with the given path.
-func (r *Controller[T, RequestData]) Put(path string, fn ControllerHandler[T, RequestData]) {
-	r.root.Put(path, fn)
+func (r *Controller[T, RequestData]) Put(path string, fn ControllerHandler[T, RequestData], middleware ...Middleware[T]) {
+	r.root.Put(path, fn, middleware...)
 }
 
 // Patch registers a PATCH handler with the given path.
-func (r *Controller[T, RequestData]) Patch(path string, fn ControllerHandler[T, RequestData]) {
-	r.root.Patch(path, fn)
+func (r *Controller[T, RequestData]) Patch(path string, fn ControllerHandler[T, RequestData], middleware ...Middleware[T]) {
+	r.root.Patch(path, fn, middleware...)
 }
 
 // Delete registers a DELETE handler with the given path.
-func (r *Controller[T, RequestData]) Delete(path string, fn ControllerHandler[T, RequestData]) {
-	r.root.Delete(path, fn)
+func (r *Controller[T, RequestData]) Delete(path string, fn ControllerHandler[T, RequestData], middleware ...Middleware[T]) {
+	r.root.Delete(path, fn, middleware...)
 }
 
 // Group returns a new ControllerGroup with the given prefix.
@@ -0,0 +1,115 @@
+package httprouter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+type (
+	// FallibleHandler is the signature for handlers that return an error
+	// instead of panicking. Use Fallible to register them with a router.
+	FallibleHandler[T RequestContext] func(context.Context, T) error
+
+	// HTTPError is an error that is rendered with the given status. Message
+	// is safe to show to users while Err is the internal cause, which is
+	// logged but never rendered.
+	HTTPError struct {
+		Status  int
+		Message string
+		Err     error
+	}
+
+	// errorReporter is stored in the request Values by middleware that
+	// renders errors. See SetErrorReporter.
+	errorReporter func(ctx context.Context, err error)
+)
+
+// NewHTTPError returns an HTTPError with the given status, public message, and
+// internal cause. message and err can be empty.
+func NewHTTPError(status int, message string, err error) *HTTPError {
+	return &HTTPError{Status: status, Message: message, Err: err}
+}
+
+// Error implements the error interface.
+func (e *HTTPError) Error() string {
+	msg := strconv.Itoa(e.Status) + " " + http.StatusText(e.Status)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+
+	return msg
+}
+
+// Unwrap returns the internal cause of the error.
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// PublicMessage returns the message to show users, falling back to the status
+// text when Message is empty.
+func (e *HTTPError) PublicMessage() string {
+	if e.Message != "" {
+		return e.Message
+	}
+
+	return http.StatusText(e.Status)
+}
+
+// AsHTTPError returns err as an HTTPError. An *http.MaxBytesError, returned
+// when reading a request body that's too large, is treated as a 413 Request
+// Entity Too Large. Other errors that don't wrap an HTTPError are treated as
+// internal server errors with err as the cause.
+func AsHTTPError(err error) *HTTPError {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return &HTTPError{Status: http.StatusRequestEntityTooLarge, Err: err}
+	}
+
+	return &HTTPError{Status: http.StatusInternalServerError, Err: err}
+}
+
+// Fallible adapts a FallibleHandler so it can be registered as a route.
+// Returned errors are passed to ReportError.
+//
+//	router.Get("/users/:id", httprouter.Fallible(showUser))
+func Fallible[T RequestContext](fn FallibleHandler[T]) Handler[T] {
+	return func(ctx context.Context, rc T) {
+		if err := fn(ctx, rc); err != nil {
+			ReportError(ctx, rc, err)
+		}
+	}
+}
+
+// SetErrorReporter sets the function ReportError calls for the current
+// request. It's used by middleware that renders errors, like
+// middleware.ErrorRenderer.
+func SetErrorReporter(rc RequestContext, fn func(ctx context.Context, err error)) {
+	Set(rc, errorReporter(fn))
+}
+
+// ReportError passes err to the error reporter of the request. When no
+// reporter is set, the buffered response is replaced with a plain text
+// response using the status and public message of the error.
+func ReportError(ctx context.Context, rc RequestContext, err error) {
+	if report, ok := Get[errorReporter](rc); ok {
+		report(ctx, err)
+		return
+	}
+
+	httpErr := AsHTTPError(err)
+	rc.Response().Clear()
+	rc.Response().Header().Set("Content-Type", "text/plain; charset=utf-8")
+	rc.Response().WriteHeader(httpErr.Status)
+	_, _ = rc.Response().Write([]byte(httpErr.PublicMessage()))
+}
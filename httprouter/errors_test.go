@@ -0,0 +1,84 @@
+package httprouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPError(t *testing.T) {
+	cause := errors.New("record not found")
+	err := NewHTTPError(http.StatusNotFound, "User not found", cause)
+
+	require.Equal(t, "404 Not Found: User not found: record not found", err.Error())
+	require.ErrorIs(t, err, cause)
+	require.Equal(t, "User not found", err.PublicMessage())
+	require.Equal(t, "Forbidden", NewHTTPError(http.StatusForbidden, "", nil).PublicMessage())
+
+	require.Equal(t, err, AsHTTPError(fmt.Errorf("wrapped: %w", err)))
+
+	internal := AsHTTPError(cause)
+	require.Equal(t, http.StatusInternalServerError, internal.Status)
+	require.Equal(t, cause, internal.Err)
+	require.Equal(t, "Internal Server Error", internal.PublicMessage())
+}
+
+func TestFallible(t *testing.T) {
+	router := New(func(r RequestContext) RequestContext {
+		return r
+	})
+
+	router.Get("/ok", Fallible(func(ctx context.Context, r RequestContext) error {
+		_, _ = r.Response().Write([]byte("ok"))
+		return nil
+	}))
+	router.Get("/missing", Fallible(func(ctx context.Context, r RequestContext) error {
+		_, _ = r.Response().Write([]byte("partial"))
+		return NewHTTPError(http.StatusNotFound, "User not found", nil)
+	}))
+	router.Get("/broken", Fallible(func(ctx context.Context, r RequestContext) error {
+		return errors.New("secret database error")
+	}))
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "ok", res.Body.String())
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	require.Equal(t, http.StatusNotFound, res.Code)
+	require.Equal(t, "User not found", res.Body.String())
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/broken", nil))
+	require.Equal(t, http.StatusInternalServerError, res.Code)
+	require.Equal(t, "Internal Server Error", res.Body.String())
+}
+
+func TestFallible_ErrorReporter(t *testing.T) {
+	router := New(func(r RequestContext) RequestContext {
+		return r
+	})
+
+	var reported error
+	router.Use(func(ctx context.Context, r RequestContext, next Handler[RequestContext]) {
+		SetErrorReporter(r, func(ctx context.Context, err error) {
+			reported = err
+		})
+		next(ctx, r)
+	})
+
+	cause := errors.New("oops")
+	router.Get("/", Fallible(func(ctx context.Context, r RequestContext) error {
+		return cause
+	}))
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, cause, reported)
+}
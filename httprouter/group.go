@@ -9,9 +9,17 @@ type (
 	// Group is a collection of routes that share a common prefix and set of middleware.
 	Group[T RequestContext] struct {
 		prefix     string
-		middleware []func(context.Context, T, Handler[T])
+		middleware []Middleware[T]
 		parent     Registerable[T]
 	}
+
+	// lazyRegisterable is implemented by the Registerable types in this
+	// package. Groups register routes through it so their middleware are read
+	// when the route is served, which allows Use to be called after routes are
+	// defined while keeping the stack visible to Router.Routes.
+	lazyRegisterable[T RequestContext] interface {
+		rawMatchStack(method string, path string, fn Handler[T], stack func() []Middleware[T])
+	}
 )
 
 var _ Routable[*rootRequestContext] = (*Group[*rootRequestContext])(nil)
@@ -22,45 +30,59 @@ func NewGroup[T RequestContext](parent Registerable[T], prefix string) *Group[T]
 	return &Group[T]{
 		prefix:     prefix,
 		parent:     parent,
-		middleware: make([]func(context.Context, T, Handler[T]), 0),
+		middleware: make([]Middleware[T], 0),
 	}
 }
 
-func (g *Group[T]) RawMatch(method string, path string, fn Handler[T]) {
-	g.parent.RawMatch(method, joinURL(g.prefix, path), g.wrap(fn))
+// RawMatch implements the Registerable interface and forwards the route to the
+// parent with the group prefix and middleware applied.
+func (g *Group[T]) RawMatch(method string, path string, fn Handler[T], middleware ...Middleware[T]) {
+	middleware = append([]Middleware[T](nil), middleware...)
+	g.rawMatchStack(method, path, fn, func() []Middleware[T] {
+		return middleware
+	})
+}
+
+// rawMatchStack implements lazyRegisterable and prepends the group middleware
+// to the stack.
+func (g *Group[T]) rawMatchStack(method string, path string, fn Handler[T], stack func() []Middleware[T]) {
+	registerStack(g.parent, method, joinURL(g.prefix, path), fn, func() []Middleware[T] {
+		return appendStack(g.middleware, stack())
+	})
 }
 
 // Match registers a route with the given method and path
-func (g *Group[T]) Match(method string, path string, fn Handler[T]) {
-	g.parent.RawMatch(method, joinURL(g.prefix, path), g.wrap(fn))
+func (g *Group[T]) Match(method string, path string, fn Handler[T], middleware ...Middleware[T]) {
+	g.RawMatch(method, path, fn, middleware...)
 }
 
 // Get registers a GET route with the given handler
-func (g *Group[T]) Get(path string, fn Handler[T]) {
-	g.Match(http.MethodGet, path, fn)
+func (g *Group[T]) Get(path string, fn Handler[T], middleware ...Middleware[T]) {
+	g.Match(http.MethodGet, path, fn, middleware...)
 }
 
 // Post registers a POST route with the given handler
-func (g *Group[T]) Post(path string, fn Handler[T]) {
-	g.Match(http.MethodPost, path, fn)
+func (g *Group[T]) Post(path string, fn Handler[T], middleware ...Middleware[T]) {
+	g.Match(http.MethodPost, path, fn, middleware...)
 }
 
 // Put registers a PUT route with the given handler
-func (g *Group[T]) Put(path string, fn Handler[T]) {
-	g.Match(http.MethodPut, path, fn)
+func (g *Group[T]) Put(path string, fn Handler[T], middleware ...Middleware[T]) {
+	g.Match(http.MethodPut, path, fn, middleware...)
 }
 
 // Patch registers a PATCH route with the given handler
-func (g *Group[T]) Patch(path string, fn Handler[T]) {
-	g.Match(http.MethodPatch, path, fn)
+func (g *Group[T]) Patch(path string, fn Handler[T], middleware ...Middleware[T]) {
+	g.Match(http.MethodPatch, path, fn, middleware...)
 }
 
 // Delete registers a DELETE route with the given handler
-func (g *Group[T]) Delete(path string, fn Handler[T]) {
-	g.Match(http.MethodDelete, path, fn)
+func (g *Group[T]) Delete(path string, fn Handler[T], middleware ...Middleware[T]) {
+	g.Match(http.MethodDelete, path, fn, middleware...)
 }
 
-// Use registers a middleware that will run before the handlers of this group and subgroups.
+// Use registers a middleware that will run before the handlers of this group
+// and subgroups, including routes that were registered before Use was called.
 func (g *Group[T]) Use(fn func(context.Context, T, Handler[T])) {
 	g.middleware = append(g.middleware, fn)
 }
@@ -71,20 +93,21 @@ func (g *Group[T]) Group(prefix string) *Group[T] {
 	return NewGroup[T](g, prefix)
 }
 
-// wrap takes a Handler and ensures that this groups middleware is run before the handler is called
-func (g *Group[T]) wrap(fn Handler[T]) Handler[T] {
-	return func(ctx context.Context, r T) {
-		handler := fn
-
-		for i := len(g.middleware) - 1; i >= 0; i-- {
-			currentHandler := handler
-			middleware := g.middleware[i]
+// registerStack registers a route whose middleware are read from stack when
+// the route is served. Registerable types outside of this package receive the
+// stack as a single middleware.
+func registerStack[T RequestContext](parent Registerable[T], method string, path string, fn Handler[T], stack func() []Middleware[T]) {
+	if lazy, ok := parent.(lazyRegisterable[T]); ok {
+		lazy.rawMatchStack(method, path, fn, stack)
+		return
+	}
 
-			handler = func(ctx context.Context, r T) {
-				middleware(ctx, r, currentHandler)
-			}
-		}
+	parent.RawMatch(method, path, lazyHandler(stack, fn))
+}
 
-		handler(ctx, r)
-	}
+// appendStack returns a new slice containing middleware followed by stack.
+func appendStack[T RequestContext](middleware []Middleware[T], stack []Middleware[T]) []Middleware[T] {
+	combined := make([]Middleware[T], 0, len(middleware)+len(stack))
+	combined = append(combined, middleware...)
+	return append(combined, stack...)
 }
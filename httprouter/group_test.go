@@ -20,7 +20,7 @@ func TestGroup(t *testing.T) {
 	}
 
 	tests := map[string]struct {
-		routerFn func(string, Handler[*rootRequestContext])
+		routerFn func(string, Handler[*rootRequestContext], ...Middleware[*rootRequestContext])
 		method   string
 	}{
 		"GET":    {method: http.MethodGet, routerFn: group.Get},
@@ -122,3 +122,31 @@ func TestGroup_PrefixRoot(t *testing.T) {
 
 	require.Equal(t, http.StatusOK, res.Code)
 }
+
+func TestGroup_UseAfterRoute(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	group := router.Group("/api")
+	subgroup := group.Group("/v1")
+
+	var called []string
+	subgroup.Get("/hello", func(ctx context.Context, r *rootRequestContext) {
+		called = append(called, "handler")
+	})
+
+	// Middleware added after the route is registered still apply to it
+	group.Use(func(ctx context.Context, r *rootRequestContext, next Handler[*rootRequestContext]) {
+		called = append(called, "group")
+		next(ctx, r)
+	})
+	subgroup.Use(func(ctx context.Context, r *rootRequestContext, next Handler[*rootRequestContext]) {
+		called = append(called, "subgroup")
+		next(ctx, r)
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/v1/hello", nil))
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, []string{"group", "subgroup", "handler"}, called)
+	require.Len(t, router.Routes()[0].Middleware, 2)
+}
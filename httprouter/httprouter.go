@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/blakewilliams/amaro/httprouter/internal/radical"
 )
@@ -12,6 +13,9 @@ type (
 	// Middleware is a function that wraps a handler and other middlewares. They
 	// accept a context, the RequestContext, and the next handler to be called.
 	// If the `next` handler is not called, the request halts.
+	//
+	// A derived context can be passed to `next`, and the RequestContext's
+	// request will be updated to use it.
 	Middleware[T RequestContext] func(context.Context, T, Handler[T])
 
 	// Handler is a function that handles a request.
@@ -19,37 +23,57 @@ type (
 
 	// Router represents the primary router for the application.
 	Router[T RequestContext] struct {
-		routes           []*route[T]
-		tree             *radical.Node[*route[T]]
-		middleware       []func(context.Context, T, Handler[T])
+		routes []*route[T]
+		names  map[string]string
+		// methods are the registered methods, in the order they were first
+		// registered
+		methods          []string
+		trees            map[string]*radical.Node[*route[T]]
+		middleware       []Middleware[T]
 		metal            []func(w http.ResponseWriter, r *http.Request, next http.Handler)
 		initT            func(RequestContext) T
 		anyRoutesDefined bool
+		options          options
+		// handler is the metal middleware stack wrapping serve
+		handler http.Handler
+		// notFound is the middleware stack wrapping the default 404 handler
+		notFound Handler[T]
+		// autoOptions is the middleware stack wrapping the handler of
+		// automatic OPTIONS responses
+		autoOptions Handler[T]
+		// transform is applied to request path segments before they are
+		// compared to static route segments
+		transform func(string) string
+		// contexts pools the RequestContext storage between requests
+		contexts sync.Pool
 	}
 
 	// Registerable is an interface that can be implemented by types that want
 	// to register routes with a router. This allows the router to be extended
 	// by internal or external packages like Group, and Controller.
 	Registerable[T RequestContext] interface {
-		// RawMatch registers a route with the given method and path
-		RawMatch(method string, path string, fn Handler[T])
+		// RawMatch registers a route with the given method and path. The
+		// middleware are run before fn, after the middleware of the
+		// Registerable types above it.
+		RawMatch(method string, path string, fn Handler[T], middleware ...Middleware[T])
 	}
 
 	// Routable is an interface that can be implemented by types that want to
 	// register routes with a router.
 	Routable[T RequestContext] interface {
-		// Match registers a route with the given method and path
-		Match(method string, path string, fn Handler[T])
+		// Match registers a route with the given method and path. The
+		// middleware passed only run for this route.
+		Match(method string, path string, fn Handler[T], middleware ...Middleware[T])
 		// Get registers a GET route with the given path
-		Get(method string, fn Handler[T])
+		Get(path string, fn Handler[T], middleware ...Middleware[T])
 		// Post registers a POST route with the given path
-		Post(method string, fn Handler[T])
+		Post(path string, fn Handler[T], middleware ...Middleware[T])
 		// Put registers a PUT route with the given path
-		Put(method string, fn Handler[T])
+		Put(path string, fn Handler[T], middleware ...Middleware[T])
 		// Patch registers a PATCH route with the given path
-		Patch(method string, fn Handler[T])
+		Patch(path string, fn Handler[T], middleware ...Middleware[T])
 		// Delete registers a DELETE route with the given path
-		Delete(method string, fn Handler[T])
+		Delete(path string, fn Handler[T], middleware ...Middleware[T])
 
 		// Use registers a middleware function that is run before each request
 		// for this group and all groups below it.
@@ -68,59 +92,127 @@ var _ Registerable[*rootRequestContext] = (*Router[*rootRequestContext])(nil)
 // New returns a new router with the given RequestContext type. The function
 // passed to this function is used to initialize the RequestContext for each
 // request which is then passed to the relevant route handler.
-func New[T RequestContext](init func(RequestContext) T) *Router[T] {
+//
+// Options can be passed to change how request paths are matched, e.g.
+// WithTrailingSlash and WithCleanPath.
+func New[T RequestContext](init func(RequestContext) T, opts ...Option) *Router[T] {
 	r := &Router[T]{
-		tree:       radical.New[*route[T]](),
-		middleware: make([]func(context.Context, T, Handler[T]), 0),
+		trees:      make(map[string]*radical.Node[*route[T]]),
+		names:      make(map[string]string),
+		middleware: make([]Middleware[T], 0),
 		initT:      init,
+		transform:  unescapeSegment,
 	}
+	r.contexts.New = func() any { return newPooledRequestContext() }
+
+	for _, opt := range opts {
+		opt(&r.options)
+	}
+
+	if r.options.caseInsensitive {
+		r.transform = foldSegment
+	}
+
+	r.buildHandler()
+	r.notFound = compose(r.middleware, notFoundHandler[T])
+	r.autoOptions = compose(r.middleware, autoOptionsHandler[T])
 
 	return r
 }
 
 // RawMatch implements the Registerable interface and registers a route with the
 // router.
-func (r *Router[T]) RawMatch(method string, path string, handler Handler[T]) {
-	r.Match(method, path, handler)
+func (r *Router[T]) RawMatch(method string, path string, handler Handler[T], middleware ...Middleware[T]) {
+	r.Match(method, path, handler, middleware...)
+}
+
+// Match registers a route with the router. The middleware passed are run after
+// the router middleware and only for this route.
+//
+// Unless WithoutAutoOptions is passed to New, OPTIONS requests that don't
+// match an OPTIONS route, including wildcard routes, are answered with a 204
+// listing the methods of the matching routes in the `Allow` header. CORS
+// preflight requests run the middleware of the route matching their
+// `Access-Control-Request-Method` header, including group and route
+// middleware, so middleware like CORS can answer them wherever they're
+// registered. Other OPTIONS requests only run the router middleware.
+func (r *Router[T]) Match(method string, path string, handler Handler[T], middleware ...Middleware[T]) {
+	r.register(method, path, handler, middleware, nil)
+}
+
+// rawMatchStack implements lazyRegisterable. The stack is run after the router
+// middleware and is read each time the route is served.
+func (r *Router[T]) rawMatchStack(method string, path string, handler Handler[T], stack func() []Middleware[T]) {
+	r.register(method, path, lazyHandler(stack, handler), nil, stack)
 }
 
-// Match registers a route with the router.
-func (r *Router[T]) Match(method string, path string, handler Handler[T]) {
+// register adds a route to the router. The middleware are run after the router
+// middleware, followed by the middleware returned by stack, if any.
+func (r *Router[T]) register(method string, path string, handler Handler[T], middleware []Middleware[T], stack func() []Middleware[T]) {
 	r.anyRoutesDefined = true
 
-	route := newRoute[T](method, path, r.wrap(handler))
+	static := make([]Middleware[T], 0, len(r.middleware)+len(middleware))
+	static = append(static, r.middleware...)
+	static = append(static, middleware...)
+
+	route := newRoute[T](method, path, compose(static, handler))
+	route.middleware = static
+	route.stack = stack
+
+	if stack != nil {
+		route.preflight = compose(static, lazyHandler(stack, autoOptionsHandler[T]))
+	} else {
+		route.preflight = compose(static, autoOptionsHandler[T])
+	}
 	r.routes = append(r.routes, route)
 
-	pathParts := make([]string, 0, len(route.parts)+1)
-	pathParts = append(pathParts, method)
-	pathParts = append(pathParts, route.parts...)
+	pathParts := make([]string, 0, len(route.parts))
+	for _, part := range route.parts {
+		if r.options.caseInsensitive && !strings.HasPrefix(part, ":") && !strings.HasPrefix(part, "*") {
+			part = strings.ToLower(part)
+		}
+		pathParts = append(pathParts, part)
+	}
 
-	r.tree.Add(pathParts, route)
+	r.treeFor(method).Add(pathParts, route)
 }
 
-// Get registers a GET route with the router.
-func (r *Router[T]) Get(path string, handler Handler[T]) {
-	r.Match(http.MethodGet, path, handler)
+// treeFor returns the routing tree for the given method, creating it if
+// necessary.
+func (r *Router[T]) treeFor(method string) *radical.Node[*route[T]] {
+	tree, ok := r.trees[method]
+	if !ok {
+		tree = radical.New[*route[T]]()
+		r.trees[method] = tree
+		r.methods = append(r.methods, method)
+	}
+
+	return tree
 }
 
 // Get registers a GET route with the router.
-func (r *Router[T]) Post(path string, handler Handler[T]) {
-	r.Match(http.MethodPost, path, handler)
+func (r *Router[T]) Get(path string, handler Handler[T], middleware ...Middleware[T]) {
+	r.Match(http.MethodGet, path, handler, middleware...)
+}
+
+// Post registers a POST route with the router.
+func (r *Router[T]) Post(path string, handler Handler[T], middleware ...Middleware[T]) {
+	r.Match(http.MethodPost, path, handler, middleware...)
 }
 
 // Put registers a PUT route with the router.
-func (r *Router[T]) Put(path string, handler Handler[T]) {
-	r.Match(http.MethodPut, path, handler)
+func (r *Router[T]) Put(path string, handler Handler[T], middleware ...Middleware[T]) {
+	r.Match(http.MethodPut, path, handler, middleware...)
 }
 
 // Patch registers a PATCH route with the router.
-func (r *Router[T]) Patch(path string, handler Handler[T]) {
-	r.Match(http.MethodPatch, path, handler)
+func (r *Router[T]) Patch(path string, handler Handler[T], middleware ...Middleware[T]) {
+	r.Match(http.MethodPatch, path, handler, middleware...)
 }
 
 // Delete registers a DELETE route with the router.
-func (r *Router[T]) Delete(path string, handler Handler[T]) {
-	r.Match(http.MethodDelete, path, handler)
+func (r *Router[T]) Delete(path string, handler Handler[T], middleware ...Middleware[T]) {
+	r.Match(http.MethodDelete, path, handler, middleware...)
 }
 
 // Use registers a middleware that will be run before each handler, including
@@ -131,6 +223,8 @@ func (r *Router[T]) Use(fn func(context.Context, T, Handler[T])) {
 	}
 
 	r.middleware = append(r.middleware, fn)
+	r.notFound = compose(r.middleware, notFoundHandler[T])
+	r.autoOptions = compose(r.middleware, autoOptionsHandler[T])
 }
 
 // UseMetal registers a "metal" middleware (net/http based) that will be run
@@ -143,6 +237,7 @@ func (r *Router[T]) UseMetal(fn func(w http.ResponseWriter, r *http.Request, nex
 	}
 
 	r.metal = append(r.metal, fn)
+	r.buildHandler()
 }
 
 // Group returns a new route group with the given prefix. The group can define
@@ -153,69 +248,120 @@ func (r *Router[T]) Group(prefix string) *Group[T] {
 
 // ServeHTTP implements the http.Handler interface.
 func (r *Router[T]) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
-	httpHandler := func(rw http.ResponseWriter, req *http.Request) {
-		// Run middleware and call route handler
-		method := req.Method
-		normalizedPath := normalizeRoutePath(req.URL.Path)
-		lookup := []string{method}
-		lookup = append(lookup, normalizedPath...)
-
-		var handler func(context.Context, T)
-		var params map[string]string
-		var path string
-
-		ok, value := r.tree.Value(lookup)
-		if ok {
-			handler = value.handler
-			path = value.Path
-
-			var ok bool
-			ok, params = value.match(req)
-			if !ok && !value.isWildcard() {
-				// This should never actually get hit in real code but would
-				// indicate a bug in the framework.
-				panic("route did not match request. this is a bug in router. please open an issue reporting this error and how to reproduce it.")
+	r.handler.ServeHTTP(rw, req)
+}
+
+// serve finds the route for the request, runs the middleware and route
+// handler, then flushes the response. It runs inside the metal middleware
+// stack.
+//
+// The RequestContext and its params are reused between requests, so they must
+// not be retained after the handler returns.
+func (r *Router[T]) serve(rw http.ResponseWriter, req *http.Request) {
+	method := req.Method
+	// Use the escaped path so encoded slashes in params don't split
+	// segments. Segments are decoded after they're matched.
+	reqPath := req.URL.EscapedPath()
+
+	if r.options.cleanPath != PathStrict {
+		if cleaned := cleanPath(reqPath); cleaned != reqPath {
+			if r.options.cleanPath == PathRedirect {
+				redirectToPath(rw, req, cleaned)
+				return
 			}
-		} else {
-			params = map[string]string{}
-			handler = r.wrap(func(ctx context.Context, rctx T) {
-				rctx.Response().WriteHeader(http.StatusNotFound)
-			})
+
+			reqPath = cleaned
 		}
+	}
 
-		reqCtx := NewRequestContext(req, rw, path, params)
-		handler(
-			req.Context(),
-			r.initT(reqCtx),
-		)
+	reqCtx := r.contexts.Get().(*rootRequestContext)
+	value, params, ok := r.find(method, reqPath, reqCtx.paramValues[:0])
 
-		reqCtx.Response().Flush()
+	// Prefer a route that differs only by a trailing slash over a miss or
+	// a wildcard route, since wildcards are typically catch-alls.
+	if r.options.trailingSlash != PathStrict && (!ok || value.isWildcard()) && reqPath != "/" {
+		altPath := toggleTrailingSlash(reqPath)
+
+		if altValue, altParams, altOk := r.find(method, altPath, params[:0]); altOk && !altValue.isWildcard() {
+			if r.options.trailingSlash == PathRedirect {
+				r.contexts.Put(reqCtx)
+				redirectToPath(rw, req, altPath)
+				return
+			}
+
+			value, params, ok = altValue, altParams, altOk
+		} else if ok {
+			// Capture the original wildcard params again since the
+			// failed lookup may have overwritten them.
+			value, params, ok = r.find(method, reqPath, params[:0])
+		}
 	}
 
-	for i := len(r.metal) - 1; i >= 0; i-- {
-		currentHandler := httpHandler
-		m := r.metal[i]
+	reqCtx.paramValues = params
+	handler := r.notFound
+	matchedPath := ""
+
+	if ok {
+		handler = value.handler
+		matchedPath = value.Path
+		value.setParams(params, reqCtx.params)
+	} else if method == http.MethodOptions && !r.options.noAutoOptions {
+		if allow := r.allowHeader(reqPath, params); allow != "" {
+			rw.Header().Set("Allow", allow)
+			handler = r.autoOptions
+
+			if route, routeParams, ok := r.preflightRoute(req, reqPath, params[:0]); ok {
+				handler = route.preflight
+				matchedPath = route.Path
+				reqCtx.paramValues = routeParams
+				route.setParams(routeParams, reqCtx.params)
+			}
+		}
+	}
 
-		httpHandler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
-			m(rw, req, http.HandlerFunc(currentHandler))
-		})
+	reqCtx.reset(req, rw, matchedPath)
+	handler(
+		req.Context(),
+		r.initT(reqCtx),
+	)
+
+	_, _ = reqCtx.Response().Flush()
+
+	if reqCtx.reusable() {
+		reqCtx.release()
+		r.contexts.Put(reqCtx)
+	}
+}
+
+// find returns the route registered for the given method and escaped path.
+// The raw param values are appended to params.
+func (r *Router[T]) find(method string, path string, params []string) (*route[T], []string, bool) {
+	tree, ok := r.trees[method]
+	if !ok {
+		return nil, params, false
 	}
 
-	httpHandler(rw, req)
+	return tree.Lookup(path, r.transform, params)
 }
 
-func (r *Router[T]) wrap(fn Handler[T]) func(context.Context, T) {
-	handler := fn
+// buildHandler wraps serve in the metal middleware stack.
+func (r *Router[T]) buildHandler() {
+	var handler http.Handler = http.HandlerFunc(r.serve)
 
-	for i := len(r.middleware) - 1; i >= 0; i-- {
+	for i := len(r.metal) - 1; i >= 0; i-- {
 		currentHandler := handler
-		middleware := r.middleware[i]
-		handler = func(ctx context.Context, reqCtx T) {
-			middleware(ctx, reqCtx, currentHandler)
-		}
+		m := r.metal[i]
+
+		handler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			m(rw, req, currentHandler)
+		})
 	}
 
-	return handler
+	r.handler = handler
+}
+
+func notFoundHandler[T RequestContext](ctx context.Context, rctx T) {
+	rctx.Response().WriteHeader(http.StatusNotFound)
 }
 
 func joinURL(prefix string, path string) string {
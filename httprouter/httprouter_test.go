@@ -20,7 +20,7 @@ func TestRouter(t *testing.T) {
 	}
 
 	tests := map[string]struct {
-		routerFn func(string, Handler[*rootRequestContext])
+		routerFn func(string, Handler[*rootRequestContext], ...Middleware[*rootRequestContext])
 		method   string
 	}{
 		"GET":    {method: http.MethodGet, routerFn: router.Get},
@@ -209,3 +209,289 @@ func TestRouter_Wildcard_PanicBug(t *testing.T) {
 func WithBasicRequestContext(rctx RequestContext) *rootRequestContext {
 	return rctx.(*rootRequestContext)
 }
+
+func TestRouter_TrailingSlash(t *testing.T) {
+	tests := map[string]struct {
+		policy   PathPolicy
+		route    string
+		reqPath  string
+		method   string
+		status   int
+		location string
+	}{
+		"strict missing slash":          {policy: PathStrict, route: "/users/", reqPath: "/users", method: http.MethodGet, status: http.StatusNotFound},
+		"strict extra slash":            {policy: PathStrict, route: "/users", reqPath: "/users/", method: http.MethodGet, status: http.StatusNotFound},
+		"redirect extra slash":          {policy: PathRedirect, route: "/users", reqPath: "/users/", method: http.MethodGet, status: http.StatusMovedPermanently, location: "/users"},
+		"redirect missing slash":        {policy: PathRedirect, route: "/users/", reqPath: "/users", method: http.MethodGet, status: http.StatusMovedPermanently, location: "/users/"},
+		"redirect preserves query":      {policy: PathRedirect, route: "/users", reqPath: "/users/?page=2", method: http.MethodGet, status: http.StatusMovedPermanently, location: "/users?page=2"},
+		"redirect post preserve method": {policy: PathRedirect, route: "/users", reqPath: "/users/", method: http.MethodPost, status: http.StatusPermanentRedirect, location: "/users"},
+		"match extra slash":             {policy: PathMatch, route: "/users", reqPath: "/users/", method: http.MethodGet, status: http.StatusOK},
+		"match missing slash":           {policy: PathMatch, route: "/users/:id/", reqPath: "/users/5", method: http.MethodGet, status: http.StatusOK},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			router := New(WithBasicRequestContext, WithTrailingSlash(tc.policy))
+			router.Match(tc.method, tc.route, func(ctx context.Context, r *rootRequestContext) {})
+
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, tc.reqPath, nil)
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, tc.status, res.Code)
+			require.Equal(t, tc.location, res.Header().Get("Location"))
+		})
+	}
+}
+
+func TestRouter_TrailingSlashPrefersRouteOverWildcard(t *testing.T) {
+	router := New(WithBasicRequestContext, WithTrailingSlash(PathRedirect))
+	router.Get("*", func(ctx context.Context, r *rootRequestContext) {
+		r.Response().WriteHeader(http.StatusNotFound)
+	})
+	router.Get("/users", func(ctx context.Context, r *rootRequestContext) {})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/users/", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusMovedPermanently, res.Code)
+	require.Equal(t, "/users", res.Header().Get("Location"))
+}
+
+func TestRouter_TrailingSlashRedirectStaysOnHost(t *testing.T) {
+	tests := map[string]struct {
+		route   string
+		reqPath string
+	}{
+		"leading slashes":  {route: "/:a/:b", reqPath: "//evil.com/"},
+		"repeated slashes": {route: "/:a/:b/:c", reqPath: "///evil.com/"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			router := New(WithBasicRequestContext, WithTrailingSlash(PathRedirect))
+			router.Get(tc.route, func(ctx context.Context, r *rootRequestContext) {})
+
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.URL.Path = tc.reqPath
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, http.StatusMovedPermanently, res.Code)
+			require.Equal(t, "/evil.com", res.Header().Get("Location"))
+		})
+	}
+}
+
+func TestRouter_CleanPath(t *testing.T) {
+	tests := map[string]struct {
+		policy   PathPolicy
+		reqPath  string
+		status   int
+		location string
+	}{
+		"strict double slash":   {policy: PathStrict, reqPath: "/users//5", status: http.StatusNotFound},
+		"redirect double slash": {policy: PathRedirect, reqPath: "/users//5", status: http.StatusMovedPermanently, location: "/users/5"},
+		"redirect dot segments": {policy: PathRedirect, reqPath: "/posts/../users/./5", status: http.StatusMovedPermanently, location: "/users/5"},
+		"match double slash":    {policy: PathMatch, reqPath: "//users//5", status: http.StatusOK},
+		"match dot segments":    {policy: PathMatch, reqPath: "/users/1/../5", status: http.StatusOK},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			router := New(WithBasicRequestContext, WithCleanPath(tc.policy))
+			router.Get("/users/:id", func(ctx context.Context, r *rootRequestContext) {
+				require.Equal(t, "5", r.Params()["id"])
+			})
+
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.URL.Path = tc.reqPath
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, tc.status, res.Code)
+			require.Equal(t, tc.location, res.Header().Get("Location"))
+		})
+	}
+}
+
+func TestRouter_AutoOptionsCatchAll(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Get("/users/:id", func(ctx context.Context, r *rootRequestContext) {})
+	router.Match(http.MethodOptions, "/*path", func(ctx context.Context, r *rootRequestContext) {
+		r.Response().WriteHeader(http.StatusTeapot)
+	})
+
+	req := httptest.NewRequest(http.MethodOptions, "/users/1", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusTeapot, res.Code)
+	require.Empty(t, res.Header().Get("Allow"))
+}
+
+func TestRouter_CleanRouteDefinition(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Group("/api/").Get("//users", func(ctx context.Context, r *rootRequestContext) {})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "/api/users", router.routes[0].Path)
+}
+
+func TestRouter_CaseInsensitive(t *testing.T) {
+	router := New(WithBasicRequestContext, WithCaseInsensitive())
+	router.Get("/Users/:name", func(ctx context.Context, r *rootRequestContext) {
+		_, _ = r.Response().Write([]byte(r.Params()["name"]))
+	})
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/USERS/FoxMulder", nil)
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "FoxMulder", res.Body.String())
+
+	router = New(WithBasicRequestContext)
+	router.Get("/Users/:name", func(ctx context.Context, r *rootRequestContext) {})
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestRouter_EncodedParams(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Get("/files/:name", func(ctx context.Context, r *rootRequestContext) {
+		_, _ = r.Response().Write([]byte(r.Params()["name"]))
+	})
+	router.Get("/files/:name/raw", func(ctx context.Context, r *rootRequestContext) {
+		_, _ = r.Response().Write([]byte("raw " + r.Params()["name"]))
+	})
+
+	tests := map[string]struct {
+		reqPath string
+		body    string
+	}{
+		"encoded slash":      {reqPath: "/files/a%2Fb", body: "a/b"},
+		"encoded slash nest": {reqPath: "/files/a%2Fb/raw", body: "raw a/b"},
+		"space":              {reqPath: "/files/a%20b", body: "a b"},
+		"multibyte":          {reqPath: "/files/%C3%BCber", body: "über"},
+		"plus is literal":    {reqPath: "/files/a+b", body: "a+b"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.reqPath, nil)
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, http.StatusOK, res.Code)
+			require.Equal(t, tc.body, res.Body.String())
+		})
+	}
+}
+
+func TestRouter_PooledParamsReset(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Get("/users/:id", func(ctx context.Context, r *rootRequestContext) {
+		_, _ = r.Response().Write([]byte(fmt.Sprint(r.Params())))
+	})
+	router.Get("/about", func(ctx context.Context, r *rootRequestContext) {
+		_, _ = r.Response().Write([]byte(fmt.Sprint(r.Params())))
+	})
+
+	for _, tc := range []struct{ path, body string }{
+		{"/users/1", "map[id:1]"},
+		{"/about", "map[]"},
+		{"/users/2", "map[id:2]"},
+	} {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		require.Equal(t, tc.body, res.Body.String())
+	}
+}
+
+func TestRouter_AutoOptions(t *testing.T) {
+	router := New(WithBasicRequestContext)
+
+	var ran []string
+	router.Use(func(ctx context.Context, r *rootRequestContext, next Handler[*rootRequestContext]) {
+		ran = append(ran, "router")
+		next(ctx, r)
+	})
+
+	api := router.Group("/api")
+	api.Use(func(ctx context.Context, r *rootRequestContext, next Handler[*rootRequestContext]) {
+		ran = append(ran, "api")
+		next(ctx, r)
+	})
+
+	noop := func(ctx context.Context, r *rootRequestContext) {}
+	api.Get("/users/:id", noop)
+	api.Patch("/users/:id", noop)
+	api.Delete("/users/:id", noop)
+	router.Get("/custom", noop)
+	router.Match(http.MethodOptions, "/custom", func(ctx context.Context, r *rootRequestContext) {
+		r.Response().WriteHeader(http.StatusTeapot)
+	})
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/users/1", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusNoContent, res.Code)
+	require.Equal(t, "GET, PATCH, DELETE, OPTIONS", res.Header().Get("Allow"))
+	require.Equal(t, []string{"router"}, ran)
+
+	req = httptest.NewRequest(http.MethodOptions, "/custom", nil)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusTeapot, res.Code)
+
+	req = httptest.NewRequest(http.MethodOptions, "/missing", nil)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusNotFound, res.Code)
+
+	for _, route := range router.Routes() {
+		require.NotEqual(t, http.MethodOptions+" /api/users/:id", route.Method+" "+route.Path)
+	}
+
+	// Routes sharing a path with different param names are combined
+	api.Put("/users/:user_id", noop, func(ctx context.Context, r *rootRequestContext, next Handler[*rootRequestContext]) {
+		ran = append(ran, "route")
+		next(ctx, r)
+	})
+
+	ran = nil
+	req = httptest.NewRequest(http.MethodOptions, "/api/users/1", nil)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, "GET, PATCH, DELETE, PUT, OPTIONS", res.Header().Get("Allow"))
+	require.Equal(t, []string{"router"}, ran)
+
+	// Preflight requests run the middleware of the requested route
+	ran = nil
+	req = httptest.NewRequest(http.MethodOptions, "/api/users/1", nil)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusNoContent, res.Code)
+	require.Equal(t, "GET, PATCH, DELETE, PUT, OPTIONS", res.Header().Get("Allow"))
+	require.Equal(t, []string{"router", "api", "route"}, ran)
+
+	router = New(WithBasicRequestContext, WithoutAutoOptions())
+	router.Get("/", noop)
+
+	req = httptest.NewRequest(http.MethodOptions, "/", nil)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusNotFound, res.Code)
+}
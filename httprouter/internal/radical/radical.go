@@ -1,53 +1,57 @@
 // Package radical implements a basic radix trie like structure for use in the
 // router.
+//
+// Runs of static segments are compressed into a single node so that lookups
+// only branch where routes actually diverge, and params are captured while the
+// tree is walked so the path never needs to be split or matched a second time.
 package radical
 
 import (
-	"reflect"
 	"strings"
 )
 
 type (
 	// Node represents a node in the tree
 	Node[T any] struct {
-		// The path segment for this node.
-		segment string
+		// The static path segments matched by this node. Multiple segments
+		// are stored when there is only a single route through them.
+		prefix []string
 		// The handler for this node.
 		value T
 		// Is there a value set?
 		isSet bool
-		// The children of this node.
+		// The static children of this node, keyed by the first segment of
+		// their prefix.
 		children map[string]*Node[T]
+		// The child matching any single segment, e.g. `:id`.
+		named *Node[T]
+		// The child matching all remaining segments, e.g. `*path`.
+		wildcard *Node[T]
 	}
 )
 
 // New returns a new root Radix tree node
 func New[T any]() *Node[T] {
 	return &Node[T]{
-		segment:  "",
 		children: make(map[string]*Node[T], 0),
 	}
 }
 
-// Add adds a new node to the tree.
+// Add adds a new node to the tree. Segments starting with `:` match any single
+// segment and segments starting with `*` match all remaining segments.
 func (n *Node[T]) Add(segments []string, value T) {
 	currentSegment := n
 
-	for i, segment := range segments {
-		if strings.HasPrefix(segment, ":") {
-			child, ok := currentSegment.children[":named"]
-
-			if ok {
-				currentSegment = child
-				continue
-			}
+	for i := 0; i < len(segments); {
+		segment := segments[i]
 
-			currentSegment.children[":named"] = &Node[T]{
-				segment:  ":named",
-				children: make(map[string]*Node[T], 0),
+		if strings.HasPrefix(segment, ":") {
+			if currentSegment.named == nil {
+				currentSegment.named = New[T]()
 			}
 
-			currentSegment = currentSegment.children[":named"]
+			currentSegment = currentSegment.named
+			i++
 			continue
 		}
 
@@ -56,33 +60,43 @@ func (n *Node[T]) Add(segments []string, value T) {
 				panic("wildcard segments must be the last segment in a path")
 			}
 
-			_, ok := currentSegment.children["*"]
-			if ok {
+			if currentSegment.wildcard != nil {
 				panic("wildcard segments can only be used once in a path")
 			}
 
-			currentSegment.children["*"] = &Node[T]{
-				segment:  "*",
-				children: make(map[string]*Node[T], 0),
-			}
-
-			currentSegment = currentSegment.children["*"]
+			currentSegment.wildcard = New[T]()
+			currentSegment = currentSegment.wildcard
 
 			break
 		}
 
+		staticSegments := staticRun(segments[i:])
+
 		child, ok := currentSegment.children[segment]
-		if ok {
+		if !ok {
+			child = New[T]()
+			child.prefix = staticSegments
+			currentSegment.children[segment] = child
+
 			currentSegment = child
+			i += len(staticSegments)
 			continue
 		}
 
-		currentSegment.children[segment] = &Node[T]{
-			segment:  segment,
-			children: make(map[string]*Node[T], 0),
+		common := commonLength(child.prefix, staticSegments)
+		if common < len(child.prefix) {
+			// Split the child so the shared segments become their own node
+			// and the remaining segments diverge below it.
+			parent := New[T]()
+			parent.prefix = child.prefix[:common]
+			child.prefix = child.prefix[common:]
+			parent.children[child.prefix[0]] = child
+			currentSegment.children[segment] = parent
+			child = parent
 		}
 
-		currentSegment = currentSegment.children[segment]
+		currentSegment = child
+		i += common
 	}
 
 	currentSegment.value = value
@@ -93,41 +107,119 @@ func (n *Node[T]) Add(segments []string, value T) {
 // is found it returns true and the associated value T. If a match is not found
 // it returns false and the zero value of T.
 func (n *Node[T]) Value(segments []string) (bool, T) {
-	currentNode := n
-	var lastWildcard *Node[T]
-
-	for _, segment := range segments {
-		// hold onto the last wildcard node we've seen in case we need it later
-		// if routes don't match
-		if wildcard, ok := currentNode.children["*"]; ok {
-			lastWildcard = wildcard
+	path := ""
+	if len(segments) > 0 {
+		path = "/" + strings.Join(segments, "/")
+	}
+
+	value, _, ok := n.Lookup(path, nil, nil)
+	return ok, value
+}
+
+// Lookup searches the tree for a node matching the given path, e.g.
+// `/users/5`. Static segments are passed through transform, when non-nil,
+// before they're compared so that callers can decode or case fold them.
+//
+// The raw value of each named and wildcard segment is appended to params in the
+// order they appear in the path, and the extended slice is returned. Callers
+// can pass a reused slice to avoid allocating.
+func (n *Node[T]) Lookup(path string, transform func(string) string, params []string) (T, []string, bool) {
+	found, params := n.lookup(path, 0, transform, params)
+	if found == nil {
+		var zero T
+		return zero, params, false
+	}
+
+	return found.value, params, true
+}
+
+// lookup walks the tree depth first, preferring static segments over named
+// segments over wildcards, and backtracks when a branch doesn't match. The path
+// is the remaining path with a leading `/` per segment, so an empty path means
+// there are no segments left. The first skip segments of the prefix have
+// already been matched by the parent.
+func (n *Node[T]) lookup(path string, skip int, transform func(string) string, params []string) (*Node[T], []string) {
+	for _, part := range n.prefix[skip:] {
+		if path == "" {
+			return nil, params
 		}
 
-		child, ok := currentNode.children[segment]
-		if ok {
-			currentNode = child
-			continue
+		segment, rest := nextSegment(path)
+		if transformSegment(segment, transform) != part {
+			return nil, params
 		}
 
-		child, ok = currentNode.children[":named"]
-		if !ok {
-			if lastWildcard != nil {
-				return true, lastWildcard.value
-			}
+		path = rest
+	}
 
-			return false, reflect.Zero(reflect.TypeOf(n.value)).Interface().(T)
+	if path == "" {
+		if n.isSet {
+			return n, params
 		}
 
-		currentNode = child
+		return nil, params
 	}
 
-	if currentNode.isSet {
-		return true, currentNode.value
+	segment, rest := nextSegment(path)
+
+	if child, ok := n.children[transformSegment(segment, transform)]; ok {
+		if found, newParams := child.lookup(rest, 1, transform, params); found != nil {
+			return found, newParams
+		}
 	}
 
-	if lastWildcard != nil {
-		return true, lastWildcard.value
+	if n.named != nil {
+		if found, newParams := n.named.lookup(rest, 0, transform, append(params, segment)); found != nil {
+			return found, newParams
+		}
+	}
+
+	if n.wildcard != nil && n.wildcard.isSet {
+		return n.wildcard, append(params, path[1:])
+	}
+
+	return nil, params
+}
+
+// nextSegment returns the first segment of the given path and the path that
+// remains after it.
+func nextSegment(path string) (string, string) {
+	path = path[1:]
+
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		return path[:i], path[i:]
+	}
+
+	return path, ""
+}
+
+func transformSegment(segment string, transform func(string) string) string {
+	if transform == nil {
+		return segment
+	}
+
+	return transform(segment)
+}
+
+// staticRun returns a copy of the leading segments that are neither named nor
+// wildcard segments.
+func staticRun(segments []string) []string {
+	end := len(segments)
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			end = i
+			break
+		}
+	}
+
+	return append([]string(nil), segments[:end]...)
+}
+
+func commonLength(a []string, b []string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
 	}
 
-	return false, currentNode.value
+	return i
 }
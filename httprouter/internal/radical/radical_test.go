@@ -1,6 +1,7 @@
 package radical_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/blakewilliams/amaro/httprouter/internal/radical"
@@ -145,3 +146,74 @@ func TestWildcard_LastRoute(t *testing.T) {
 		root.Add([]string{"foo", "*", "bar"}, 1)
 	})
 }
+
+func TestNode_LookupParams(t *testing.T) {
+	root := radical.New[int]()
+
+	root.Add([]string{"users", ":id", "posts", ":post_id"}, 1)
+	root.Add([]string{"files", "*path"}, 2)
+
+	value, params, ok := root.Lookup("/users/5/posts/10", nil, nil)
+	require.True(t, ok)
+	require.Equal(t, 1, value)
+	require.Equal(t, []string{"5", "10"}, params)
+
+	value, params, ok = root.Lookup("/files/css/app.css", nil, nil)
+	require.True(t, ok)
+	require.Equal(t, 2, value)
+	require.Equal(t, []string{"css/app.css"}, params)
+
+	_, params, ok = root.Lookup("/users/5/comments", nil, params[:0])
+	require.False(t, ok)
+	require.Empty(t, params)
+}
+
+func TestNode_CompressedSplit(t *testing.T) {
+	root := radical.New[int]()
+
+	root.Add([]string{"api", "v1", "users"}, 1)
+	root.Add([]string{"api", "v1", "posts"}, 2)
+	root.Add([]string{"api"}, 3)
+	root.Add([]string{"api", "v2", "users"}, 4)
+
+	ok, value := root.Value([]string{"api", "v1", "users"})
+	require.True(t, ok)
+	require.Equal(t, 1, value)
+
+	ok, value = root.Value([]string{"api", "v1", "posts"})
+	require.True(t, ok)
+	require.Equal(t, 2, value)
+
+	ok, value = root.Value([]string{"api"})
+	require.True(t, ok)
+	require.Equal(t, 3, value)
+
+	ok, value = root.Value([]string{"api", "v2", "users"})
+	require.True(t, ok)
+	require.Equal(t, 4, value)
+
+	ok, _ = root.Value([]string{"api", "v1"})
+	require.False(t, ok)
+}
+
+func TestNode_Backtracking(t *testing.T) {
+	root := radical.New[int]()
+
+	root.Add([]string{"users", "new", "edit"}, 1)
+	root.Add([]string{"users", ":id", "posts"}, 2)
+
+	value, params, ok := root.Lookup("/users/new/posts", nil, nil)
+	require.True(t, ok)
+	require.Equal(t, 2, value)
+	require.Equal(t, []string{"new"}, params)
+}
+
+func TestNode_LookupTransform(t *testing.T) {
+	root := radical.New[int]()
+	root.Add([]string{"users", ":id"}, 1)
+
+	value, params, ok := root.Lookup("/USERS/Fox", strings.ToLower, nil)
+	require.True(t, ok)
+	require.Equal(t, 1, value)
+	require.Equal(t, []string{"Fox"}, params)
+}
@@ -1,20 +1,138 @@
 package metal
 
 import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"strings"
 )
 
+// MethodOverrideHeader is the header read by MethodRewrite when
+// MethodRewriteConfig.OverrideHeader is set.
+const MethodOverrideHeader = "X-HTTP-Method-Override"
+
+// MethodRewriteConfig configures the middleware returned by NewMethodRewrite.
+type MethodRewriteConfig struct {
+	// AllowedMethods are the methods POST requests can be rewritten to.
+	// Defaults to PUT, PATCH, and DELETE.
+	AllowedMethods []string
+	// OverrideHeader enables rewriting the method using the
+	// X-HTTP-Method-Override header, which is useful for clients that can
+	// only send GET and POST requests.
+	OverrideHeader bool
+}
+
 // MethodRewrite rewrites the HTTP method based on the _method parameter
 // passed when the request type is POST. This is useful when working with HTTP
 // forms since form only supports GET and POST methods.
+//
+// The _method parameter of urlencoded forms is read from the body. Multipart
+// forms aren't parsed so uploads can be streamed and limited by handlers.
+// Instead, the start of the body is read to find a _method field, which must
+// be the first field of the form, e.g. the first hidden input. The body is left
+// intact for handlers. The _method parameter of multipart forms can also be
+// passed in the query string, e.g. `<form action="/avatar?_method=PUT">`.
+//
+// Requests can only be rewritten to PUT, PATCH, or DELETE. See
+// NewMethodRewrite to configure the allowed methods or support the
+// X-HTTP-Method-Override header.
 func MethodRewrite(rw http.ResponseWriter, r *http.Request, next http.Handler) {
-	if r.Method == http.MethodPost && r.Header.Get("Content-Type") == "application/x-www-form-urlencoded" {
+	defaultMethodRewrite(rw, r, next)
+}
+
+var defaultMethodRewrite = NewMethodRewrite(MethodRewriteConfig{})
+
+// NewMethodRewrite returns a MethodRewrite middleware using the given config.
+func NewMethodRewrite(config MethodRewriteConfig) func(http.ResponseWriter, *http.Request, http.Handler) {
+	if config.AllowedMethods == nil {
+		config.AllowedMethods = []string{http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+
+	allowed := make(map[string]bool, len(config.AllowedMethods))
+	for _, method := range config.AllowedMethods {
+		allowed[strings.ToUpper(method)] = true
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request, next http.Handler) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		method := ""
+		if config.OverrideHeader {
+			method = r.Header.Get(MethodOverrideHeader)
+		}
+
+		if method == "" {
+			method = formMethod(r)
+		}
+
+		if method = strings.ToUpper(method); allowed[method] {
+			r.Method = method
+		}
+
+		next.ServeHTTP(rw, r)
+	}
+}
+
+// multipartPeekSize is how much of a multipart body is read to find a leading
+// _method field.
+const multipartPeekSize = 1024
+
+// formMethod returns the _method parameter of urlencoded form requests, or
+// the leading _method field or query string of multipart form requests.
+func formMethod(r *http.Request) string {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
 		_ = r.ParseForm()
-		if method := r.FormValue("_method"); method != "" {
-			r.Method = strings.ToUpper(method)
+		return r.FormValue("_method")
+	case "multipart/form-data":
+		if method := multipartMethod(r, params["boundary"]); method != "" {
+			return method
 		}
+
+		return r.URL.Query().Get("_method")
+	default:
+		return ""
+	}
+}
+
+// multipartMethod returns the value of the _method field when it's the first
+// part of the multipart body. Only the start of the body is read, and it's
+// replayed to handlers by replacing the request body.
+func multipartMethod(r *http.Request, boundary string) string {
+	if boundary == "" || r.Body == nil || r.Body == http.NoBody {
+		return ""
+	}
+
+	body := bufio.NewReaderSize(r.Body, multipartPeekSize)
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{body, r.Body}
+
+	// Peek returns an error when the body is shorter than the buffer, but the
+	// bytes read so far can still be parsed.
+	peeked, _ := body.Peek(multipartPeekSize)
+
+	part, err := multipart.NewReader(bytes.NewReader(peeked), boundary).NextPart()
+	if err != nil || part.FormName() != "_method" {
+		return ""
+	}
+
+	value, err := io.ReadAll(io.LimitReader(part, 16))
+	if err != nil {
+		return ""
 	}
 
-	next.ServeHTTP(rw, r)
+	return strings.TrimSpace(string(value))
 }
@@ -1,7 +1,10 @@
 package metal
 
 import (
+	"bytes"
 	"context"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -29,3 +32,179 @@ func TestRewrite(t *testing.T) {
 	router.ServeHTTP(res, req)
 	require.Equal(t, http.StatusOK, res.Result().StatusCode)
 }
+
+type postData struct{}
+
+func (p *postData) FromRequest(context.Context, httprouter.RequestContext) bool { return true }
+
+func TestRewrite_Resources(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext { return r })
+	router.UseMetal(MethodRewrite)
+
+	controller := httprouter.NewController(router, &postData{})
+	controller.Resources("/posts", httprouter.ResourceHandlers[httprouter.RequestContext, *postData]{
+		Update: func(ctx context.Context, rc httprouter.RequestContext, p *postData) {
+			_, _ = rc.Response().Write([]byte("update " + rc.Params()["id"]))
+		},
+		Destroy: func(ctx context.Context, rc httprouter.RequestContext, p *postData) {
+			_, _ = rc.Response().Write([]byte("destroy " + rc.Params()["id"]))
+		},
+	})
+
+	for method, body := range map[string]string{"PATCH": "update 5", "DELETE": "destroy 5"} {
+		formData := url.Values{}
+		formData.Set("_method", method)
+
+		req := httptest.NewRequest(http.MethodPost, "/posts/5", strings.NewReader(formData.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+		require.Equal(t, http.StatusOK, res.Code)
+		require.Equal(t, body, res.Body.String())
+	}
+}
+
+func TestRewrite_ContentTypes(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext { return r })
+	router.UseMetal(MethodRewrite)
+	router.Patch("/", func(ctx context.Context, rc httprouter.RequestContext) {
+		_, _ = rc.Response().Write([]byte("patched " + rc.Request().FormValue("name")))
+	})
+
+	formData := url.Values{}
+	formData.Set("_method", "patch")
+	formData.Set("name", "amaro")
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(formData.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=UTF-8")
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, "patched amaro", res.Body.String())
+
+	// Multipart forms aren't parsed so they can be streamed by handlers, so
+	// only a leading _method field or the query string is used.
+	router.Post("/", func(ctx context.Context, rc httprouter.RequestContext) {
+		_, _ = rc.Response().Write([]byte("posted"))
+	})
+	router.Put("/", func(ctx context.Context, rc httprouter.RequestContext) {
+		require.Nil(t, rc.Request().MultipartForm)
+
+		reader, err := rc.Request().MultipartReader()
+		require.NoError(t, err)
+
+		var names []string
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			names = append(names, part.FormName())
+		}
+
+		_, _ = rc.Response().Write([]byte("put " + strings.Join(names, ",")))
+	})
+
+	multipartBody := func(methodFirst bool) (*bytes.Buffer, string) {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		if methodFirst {
+			require.NoError(t, writer.WriteField("_method", "PUT"))
+		}
+		file, err := writer.CreateFormFile("avatar", "avatar.png")
+		require.NoError(t, err)
+		_, _ = file.Write(bytes.Repeat([]byte("png"), 1000))
+		if !methodFirst {
+			require.NoError(t, writer.WriteField("_method", "PUT"))
+		}
+		require.NoError(t, writer.Close())
+
+		return &body, writer.FormDataContentType()
+	}
+
+	tests := map[string]struct {
+		path        string
+		methodFirst bool
+		body        string
+	}{
+		"leading field": {path: "/", methodFirst: true, body: "put _method,avatar"},
+		"query string":  {path: "/?_method=PUT", methodFirst: false, body: "put avatar,_method"},
+		"later field":   {path: "/", methodFirst: false, body: "posted"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			body, contentType := multipartBody(tc.methodFirst)
+			req := httptest.NewRequest(http.MethodPost, tc.path, body)
+			req.Header.Set("Content-Type", contentType)
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			require.Equal(t, tc.body, res.Body.String())
+		})
+	}
+}
+
+func TestRewrite_AllowedMethods(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext { return r })
+	router.UseMetal(MethodRewrite)
+	router.Post("/", func(ctx context.Context, rc httprouter.RequestContext) {
+		_, _ = rc.Response().Write([]byte("post"))
+	})
+	router.Get("/", func(ctx context.Context, rc httprouter.RequestContext) {
+		_, _ = rc.Response().Write([]byte("get"))
+	})
+
+	formData := url.Values{}
+	formData.Set("_method", "GET")
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(formData.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, "post", res.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/?_method=DELETE", nil)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, "get", res.Body.String())
+}
+
+func TestRewrite_OverrideHeader(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext { return r })
+	router.UseMetal(NewMethodRewrite(MethodRewriteConfig{
+		AllowedMethods: []string{http.MethodDelete},
+		OverrideHeader: true,
+	}))
+	router.Post("/", func(ctx context.Context, rc httprouter.RequestContext) {
+		_, _ = rc.Response().Write([]byte("post"))
+	})
+	router.Delete("/", func(ctx context.Context, rc httprouter.RequestContext) {
+		_, _ = rc.Response().Write([]byte("delete"))
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(MethodOverrideHeader, "DELETE")
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, "delete", res.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(MethodOverrideHeader, "PATCH")
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, "post", res.Body.String())
+
+	// The header is ignored unless enabled
+	router = httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext { return r })
+	router.UseMetal(MethodRewrite)
+	router.Post("/", func(ctx context.Context, rc httprouter.RequestContext) {
+		_, _ = rc.Response().Write([]byte("post"))
+	})
+
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(MethodOverrideHeader, "DELETE")
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, "post", res.Body.String())
+}
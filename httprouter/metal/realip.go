@@ -0,0 +1,163 @@
+package metal
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// RealIP returns a metal middleware that resolves the client IP and scheme
+// from the Forwarded, or X-Forwarded-For and X-Forwarded-Proto, headers set by
+// reverse proxies. trusted is a list of CIDRs or IPs of the proxies in front
+// of the application, e.g. `10.0.0.0/8`. It panics if an entry is invalid.
+//
+// The headers can be set by anyone, so they're only used when the immediate
+// peer is trusted. The client IP is the right-most address in the chain that
+// isn't trusted, which is written to `Request.RemoteAddr`. The forwarded
+// scheme, `http` or `https`, is written to `Request.URL.Scheme`.
+//
+// RealIP should be registered before other middleware so rate limiting,
+// logging, and secure cookies use the resolved values.
+func RealIP(trusted ...string) func(http.ResponseWriter, *http.Request, http.Handler) {
+	prefixes := make([]netip.Prefix, 0, len(trusted))
+	for _, cidr := range trusted {
+		prefix, err := parsePrefix(cidr)
+		if err != nil {
+			panic(fmt.Sprintf("invalid trusted proxy %q: %v", cidr, err))
+		}
+
+		prefixes = append(prefixes, prefix)
+	}
+
+	isTrusted := func(addr netip.Addr) bool {
+		for _, prefix := range prefixes {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request, next http.Handler) {
+		peer, ok := parseAddr(r.RemoteAddr)
+		if !ok || !isTrusted(peer) {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		var hops []forwardedHop
+		if header := r.Header.Values("Forwarded"); len(header) > 0 {
+			hops = parseForwarded(header)
+		} else {
+			hops = parseXForwarded(r.Header.Values("X-Forwarded-For"), r.Header.Get("X-Forwarded-Proto"))
+		}
+
+		client := forwardedHop{addr: peer}
+		for i := len(hops) - 1; i >= 0; i-- {
+			if !hops[i].addr.IsValid() {
+				break
+			}
+
+			client = hops[i]
+			if !isTrusted(client.addr) {
+				break
+			}
+		}
+
+		if client.addr != peer {
+			r.RemoteAddr = net.JoinHostPort(client.addr.String(), "0")
+		}
+
+		if client.proto == "http" || client.proto == "https" {
+			r.URL.Scheme = client.proto
+		}
+
+		next.ServeHTTP(rw, r)
+	}
+}
+
+// forwardedHop is an address in the forwarded chain and the scheme the
+// request was received with.
+type forwardedHop struct {
+	addr  netip.Addr
+	proto string
+}
+
+// parseForwarded parses the elements of RFC 7239 Forwarded headers, e.g.
+// `for=192.0.2.60;proto=https, for="[2001:db8::17]:4711"`. Addresses that
+// are unknown or obfuscated are returned as invalid.
+func parseForwarded(headers []string) []forwardedHop {
+	var hops []forwardedHop
+	for _, header := range headers {
+		for _, element := range strings.Split(header, ",") {
+			var hop forwardedHop
+			for _, pair := range strings.Split(element, ";") {
+				key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+				value = strings.Trim(value, `"`)
+
+				switch strings.ToLower(key) {
+				case "for":
+					hop.addr, _ = parseAddr(value)
+				case "proto":
+					hop.proto = strings.ToLower(value)
+				}
+			}
+
+			hops = append(hops, hop)
+		}
+	}
+
+	return hops
+}
+
+// parseXForwarded returns the hops of X-Forwarded-For headers. Proxies only
+// set X-Forwarded-Proto when it's missing, so its left-most value, set by the
+// proxy that received the request from the client, is used for every hop.
+func parseXForwarded(headers []string, proto string) []forwardedHop {
+	proto, _, _ = strings.Cut(proto, ",")
+	proto = strings.ToLower(strings.TrimSpace(proto))
+
+	var hops []forwardedHop
+	for _, header := range headers {
+		for _, value := range strings.Split(header, ",") {
+			addr, _ := parseAddr(strings.TrimSpace(value))
+			hops = append(hops, forwardedHop{addr: addr, proto: proto})
+		}
+	}
+
+	return hops
+}
+
+// parseAddr parses an IP with an optional port, e.g. `192.0.2.1`,
+// `192.0.2.1:1234`, or `[2001:db8::1]:1234`.
+func parseAddr(value string) (netip.Addr, bool) {
+	if addrPort, err := netip.ParseAddrPort(value); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(value, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap(), true
+}
+
+// parsePrefix parses a CIDR, or a single IP as a prefix containing only that
+// IP.
+func parsePrefix(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		return prefix.Masked(), err
+	}
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+}
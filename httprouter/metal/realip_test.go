@@ -0,0 +1,78 @@
+package metal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func resolve(t *testing.T, remoteAddr string, headers map[string]string) (string, string) {
+	t.Helper()
+
+	var gotAddr, gotScheme string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAddr = r.RemoteAddr
+		gotScheme = r.URL.Scheme
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	RealIP("10.0.0.0/8", "2001:db8::1")(httptest.NewRecorder(), req, handler)
+
+	return gotAddr, gotScheme
+}
+
+func TestRealIP_XForwarded(t *testing.T) {
+	addr, scheme := resolve(t, "10.0.0.1:5000", map[string]string{
+		"X-Forwarded-For":   "203.0.113.9, 198.51.100.7, 10.0.0.2",
+		"X-Forwarded-Proto": "https",
+	})
+	require.Equal(t, "198.51.100.7:0", addr)
+	require.Equal(t, "https", scheme)
+
+	addr, _ = resolve(t, "[2001:db8::1]:5000", map[string]string{
+		"X-Forwarded-For": "10.1.1.1, 10.0.0.2",
+	})
+	require.Equal(t, "10.1.1.1:0", addr)
+
+	addr, _ = resolve(t, "10.0.0.1:5000", map[string]string{
+		"X-Forwarded-For": "198.51.100.7, garbage",
+	})
+	require.Equal(t, "10.0.0.1:5000", addr)
+}
+
+func TestRealIP_Forwarded(t *testing.T) {
+	addr, scheme := resolve(t, "10.0.0.1:5000", map[string]string{
+		"Forwarded":       `for=203.0.113.9;proto=http, for="[2001:db8:cafe::17]:4711";proto=https, for=10.0.0.2`,
+		"X-Forwarded-For": "192.0.2.1",
+	})
+	require.Equal(t, "[2001:db8:cafe::17]:0", addr)
+	require.Equal(t, "https", scheme)
+
+	addr, scheme = resolve(t, "10.0.0.1:5000", map[string]string{
+		"Forwarded": "for=unknown;proto=javascript",
+	})
+	require.Equal(t, "10.0.0.1:5000", addr)
+	require.Equal(t, "", scheme)
+}
+
+func TestRealIP_Untrusted(t *testing.T) {
+	addr, scheme := resolve(t, "192.0.2.1:5000", map[string]string{
+		"X-Forwarded-For":   "203.0.113.9",
+		"X-Forwarded-Proto": "https",
+	})
+	require.Equal(t, "192.0.2.1:5000", addr)
+	require.Equal(t, "", scheme)
+}
+
+func TestRealIP_InvalidProxy(t *testing.T) {
+	require.Panics(t, func() {
+		RealIP("not-an-ip")
+	})
+}
@@ -0,0 +1,115 @@
+package httprouter
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+)
+
+// RouteInfo describes a route registered with a Router.
+type RouteInfo[T RequestContext] struct {
+	// Method is the HTTP method of the route.
+	Method string
+	// Path is the path of the route, including the prefix of any groups.
+	Path string
+	// Name is the name of the route, if one was assigned. See
+	// Router.NameRoute.
+	Name string
+	// Middleware is the full middleware stack run before the route handler,
+	// in the order it is run.
+	Middleware []Middleware[T]
+}
+
+// MiddlewareNames returns the function names of the route middleware, which is
+// useful for debugging or printing a list of routes.
+func (ri RouteInfo[T]) MiddlewareNames() []string {
+	names := make([]string, len(ri.Middleware))
+	for i, middleware := range ri.Middleware {
+		names[i] = runtime.FuncForPC(reflect.ValueOf(middleware).Pointer()).Name()
+	}
+
+	return names
+}
+
+// Routes returns the routes registered with the router in the order they were
+// registered.
+func (r *Router[T]) Routes() []RouteInfo[T] {
+	routes := make([]RouteInfo[T], len(r.routes))
+	for i, route := range r.routes {
+		middleware := append([]Middleware[T](nil), route.middleware...)
+		if route.stack != nil {
+			middleware = append(middleware, route.stack()...)
+		}
+
+		routes[i] = RouteInfo[T]{
+			Method:     route.Method,
+			Path:       route.Path,
+			Name:       route.Name,
+			Middleware: middleware,
+		}
+	}
+
+	return routes
+}
+
+// Chain composes the given middleware into a single middleware that runs them
+// in the order they're passed.
+func Chain[T RequestContext](middleware ...Middleware[T]) Middleware[T] {
+	return func(ctx context.Context, rc T, next Handler[T]) {
+		compose(middleware, next)(ctx, rc)
+	}
+}
+
+// SkipIf returns a middleware that calls the given middleware unless skip
+// returns true, in which case the next handler is called directly.
+func SkipIf[T RequestContext](skip func(context.Context, T) bool, middleware Middleware[T]) Middleware[T] {
+	return func(ctx context.Context, rc T, next Handler[T]) {
+		if skip(ctx, rc) {
+			next(ctx, rc)
+			return
+		}
+
+		middleware(ctx, rc, next)
+	}
+}
+
+// lazyHandler returns a handler that runs the middleware returned by stack
+// before handler. The stack is read on each request so middleware added to a
+// group after its routes are registered still apply to them.
+func lazyHandler[T RequestContext](stack func() []Middleware[T], handler Handler[T]) Handler[T] {
+	return func(ctx context.Context, rc T) {
+		compose(stack(), handler)(ctx, rc)
+	}
+}
+
+// compose wraps the handler in the given middleware so they're called in order
+// before the handler. The request context is synced before each middleware and
+// the handler are called so middleware only need to pass a derived context to
+// next.
+func compose[T RequestContext](middleware []Middleware[T], handler Handler[T]) Handler[T] {
+	finalHandler := handler
+	handler = func(ctx context.Context, rc T) {
+		syncContext(ctx, rc)
+		finalHandler(ctx, rc)
+	}
+
+	for i := len(middleware) - 1; i >= 0; i-- {
+		currentHandler := handler
+		currentMiddleware := middleware[i]
+
+		handler = func(ctx context.Context, rc T) {
+			syncContext(ctx, rc)
+			currentMiddleware(ctx, rc, currentHandler)
+		}
+	}
+
+	return handler
+}
+
+// syncContext replaces the request context when it differs from the context
+// passed through the middleware stack, so both views stay consistent.
+func syncContext[T RequestContext](ctx context.Context, rc T) {
+	if rc.Request().Context() != ctx {
+		rc.WithContext(ctx)
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/blakewilliams/amaro/httprouter"
+)
+
+// originalBody is the request body before it was limited, stored in
+// httprouter.Values so route specific limits replace global limits instead of
+// being capped by them.
+type originalBody struct {
+	body io.ReadCloser
+}
+
+// limitedBody fails reads without reading the body when the Content-Length of
+// the request is over the limit.
+type limitedBody struct {
+	io.ReadCloser
+	limit    int64
+	tooLarge bool
+}
+
+// BodyLimit limits request bodies to the given number of bytes. Reading a
+// larger body fails with an *http.MaxBytesError, which httprouter.AsHTTPError
+// maps to 413 Request Entity Too Large. Bodies with a larger Content-Length
+// fail on the first read, so they're never read.
+//
+// BodyLimit can be used globally and overridden for specific routes, e.g. to
+// allow larger uploads:
+//
+//	router.Use(middleware.BodyLimit[*requestContext](1 << 20))
+//	router.Post("/uploads", createUpload, middleware.BodyLimit[*requestContext](100 << 20))
+//
+// Metal middleware run before BodyLimit, so bodies they read, like urlencoded
+// forms parsed by metal.MethodRewrite, aren't limited by it. net/http limits
+// those forms to 10MB. Multipart bodies are still limited since MethodRewrite
+// only buffers their first 1KB and replays it.
+func BodyLimit[T httprouter.RequestContext](limit int64) httprouter.Middleware[T] {
+	return func(ctx context.Context, rctx T, next httprouter.Handler[T]) {
+		req := rctx.Request()
+
+		original, ok := httprouter.Get[originalBody](rctx)
+		if !ok {
+			original = originalBody{body: req.Body}
+			httprouter.Set(rctx, original)
+		}
+
+		if original.body != nil && original.body != http.NoBody {
+			req.Body = &limitedBody{
+				ReadCloser: http.MaxBytesReader(rctx.Response(), original.body, limit),
+				limit:      limit,
+				tooLarge:   req.ContentLength > limit,
+			}
+		}
+
+		next(ctx, rctx)
+	}
+}
+
+// Read implements io.Reader.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.tooLarge {
+		return 0, &http.MaxBytesError{Limit: b.limit}
+	}
+
+	return b.ReadCloser.Read(p)
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/blakewilliams/amaro/httprouter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBodyLimit(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+	router.Use(BodyLimit[httprouter.RequestContext](10))
+
+	echo := httprouter.Fallible(func(ctx context.Context, r httprouter.RequestContext) error {
+		body, err := io.ReadAll(r.Request().Body)
+		if err != nil {
+			return err
+		}
+
+		_, _ = r.Response().Write(body)
+		return nil
+	})
+	router.Post("/", echo)
+	router.Post("/large", echo, BodyLimit[httprouter.RequestContext](20))
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("small")))
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "small", res.Body.String())
+
+	// Rejected using Content-Length
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("this is too large")))
+	require.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+
+	// Rejected while reading
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("this is too large"))
+	req.ContentLength = -1
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+
+	// Route limits replace the global limit
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/large", strings.NewReader("this is too large")))
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "this is too large", res.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/large", strings.NewReader("this is much too large for the route"))
+	req.ContentLength = -1
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+}
@@ -0,0 +1,296 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/blakewilliams/amaro/httprouter"
+)
+
+type (
+	// CompressionEncoding is a content coding that Compress can use to encode
+	// response bodies. Only gzip and deflate are built in since they're the
+	// only encodings provided by the standard library. Brotli and zstd aren't
+	// included to avoid adding dependencies, but they can be added by wrapping
+	// their writers:
+	//
+	//	middleware.CompressionEncoding{
+	//		Name: "br",
+	//		Encode: func(w io.Writer, body []byte) error {
+	//			bw := brotli.NewWriter(w)
+	//			if _, err := bw.Write(body); err != nil {
+	//				return err
+	//			}
+	//			return bw.Close()
+	//		},
+	//	}
+	CompressionEncoding struct {
+		// Name is the token used in the Accept-Encoding and Content-Encoding
+		// headers, e.g. `gzip`.
+		Name string
+		// Encode writes the encoded body to w.
+		Encode func(w io.Writer, body []byte) error
+	}
+
+	// CompressConfig configures the Compress middleware.
+	CompressConfig struct {
+		// Encodings are the available encodings in order of preference, used
+		// when the client accepts multiple encodings equally. Defaults to
+		// gzip.
+		Encodings []CompressionEncoding
+		// MinSize is the smallest body, in bytes, that will be compressed.
+		// Defaults to 1024.
+		MinSize int
+		// ContentTypes are the media types that are compressed. Entries ending
+		// in `/` match any subtype, e.g. `text/`. Defaults to text, JSON,
+		// JavaScript, XML, and SVG.
+		ContentTypes []string
+	}
+)
+
+// defaultCompressContentTypes are the media types compressed when
+// CompressConfig.ContentTypes is empty.
+var defaultCompressContentTypes = []string{
+	"text/",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"application/xhtml+xml",
+	"application/rss+xml",
+	"application/atom+xml",
+	"image/svg+xml",
+}
+
+// Compress encodes the buffered response body using the best encoding
+// accepted by the client. Since it runs after the handler returns it works
+// with the complete body, so responses cleared with `Response.Clear`, like the
+// error pages rendered by ErrorHandler, are compressed correctly.
+//
+// Responses that are too small, have a content type that isn't compressible,
+// or already have a Content-Encoding are left untouched. `Vary:
+// Accept-Encoding` is added to compressible responses so caches store each
+// encoding separately.
+func Compress[T httprouter.RequestContext](config CompressConfig) httprouter.Middleware[T] {
+	if len(config.Encodings) == 0 {
+		config.Encodings = []CompressionEncoding{GzipEncoding(gzip.DefaultCompression)}
+	}
+
+	for _, encoding := range config.Encodings {
+		if encoding.Name == "" || encoding.Encode == nil {
+			panic("compression encodings require a Name and Encode func")
+		}
+	}
+
+	if config.MinSize == 0 {
+		config.MinSize = 1024
+	}
+
+	if len(config.ContentTypes) == 0 {
+		config.ContentTypes = defaultCompressContentTypes
+	}
+
+	return func(ctx context.Context, rctx T, next httprouter.Handler[T]) {
+		next(ctx, rctx)
+
+		res := rctx.Response()
+		header := res.Header()
+		body := res.Body()
+
+		if !bodyAllowed(rctx.Request().Method, res.Status()) || header.Get("Content-Encoding") != "" {
+			return
+		}
+
+		contentType := header.Get("Content-Type")
+		if contentType == "" {
+			// Detect the type before the body is encoded, otherwise net/http
+			// would detect the type of the encoded body.
+			contentType = http.DetectContentType(body)
+			header.Set("Content-Type", contentType)
+		}
+
+		if !compressible(contentType, config.ContentTypes) {
+			return
+		}
+
+		header.Add("Vary", "Accept-Encoding")
+
+		if len(body) < config.MinSize {
+			return
+		}
+
+		encoding, ok := negotiateEncoding(rctx.Request().Header.Get("Accept-Encoding"), config.Encodings)
+		if !ok {
+			return
+		}
+
+		var encoded bytes.Buffer
+		if err := encoding.Encode(&encoded, body); err != nil {
+			panic(fmt.Errorf("could not %s encode response: %w", encoding.Name, err))
+		}
+
+		res.Clear()
+		_, _ = res.Write(encoded.Bytes())
+
+		header.Set("Content-Encoding", encoding.Name)
+		header.Del("Content-Length")
+
+		// The encoded body differs from the original, so a strong ETag
+		// computed from it no longer matches byte for byte.
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+	}
+}
+
+// GzipEncoding returns a CompressionEncoding using gzip with the given
+// compression level, e.g. gzip.DefaultCompression. It panics if the level is
+// invalid.
+func GzipEncoding(level int) CompressionEncoding {
+	// Create a writer up front so invalid levels are rejected when the
+	// middleware is configured instead of on every request.
+	writer, err := gzip.NewWriterLevel(io.Discard, level)
+	if err != nil {
+		panic(fmt.Errorf("invalid gzip compression level: %w", err))
+	}
+
+	pool := &sync.Pool{}
+	pool.Put(writer)
+
+	return CompressionEncoding{
+		Name: "gzip",
+		Encode: func(w io.Writer, body []byte) error {
+			gw, ok := pool.Get().(*gzip.Writer)
+			if ok {
+				gw.Reset(w)
+			} else {
+				var err error
+				if gw, err = gzip.NewWriterLevel(w, level); err != nil {
+					return err
+				}
+			}
+			defer pool.Put(gw)
+
+			if _, err := gw.Write(body); err != nil {
+				return err
+			}
+
+			return gw.Close()
+		},
+	}
+}
+
+// DeflateEncoding returns a CompressionEncoding using deflate with the given
+// compression level, e.g. flate.DefaultCompression. It panics if the level is
+// invalid.
+func DeflateEncoding(level int) CompressionEncoding {
+	// Create a writer up front so invalid levels are rejected when the
+	// middleware is configured instead of on every request.
+	writer, err := flate.NewWriter(io.Discard, level)
+	if err != nil {
+		panic(fmt.Errorf("invalid deflate compression level: %w", err))
+	}
+
+	pool := &sync.Pool{}
+	pool.Put(writer)
+
+	return CompressionEncoding{
+		Name: "deflate",
+		Encode: func(w io.Writer, body []byte) error {
+			fw, ok := pool.Get().(*flate.Writer)
+			if ok {
+				fw.Reset(w)
+			} else {
+				var err error
+				if fw, err = flate.NewWriter(w, level); err != nil {
+					return err
+				}
+			}
+			defer pool.Put(fw)
+
+			if _, err := fw.Write(body); err != nil {
+				return err
+			}
+
+			return fw.Close()
+		},
+	}
+}
+
+// bodyAllowed reports whether a response to the given method with the given
+// status can have a body.
+func bodyAllowed(method string, status int) bool {
+	if method == http.MethodHead {
+		return false
+	}
+
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// compressible reports whether the media type of contentType matches one of
+// the given types.
+func compressible(contentType string, types []string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+	for _, t := range types {
+		if strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t) {
+			return true
+		}
+
+		if mediaType == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// negotiateEncoding returns the encoding with the highest quality in the
+// Accept-Encoding header. Ties are broken by the order of encodings.
+func negotiateEncoding(acceptEncoding string, encodings []CompressionEncoding) (CompressionEncoding, bool) {
+	if acceptEncoding == "" {
+		return CompressionEncoding{}, false
+	}
+
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		quality := 1.0
+		if key, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(key) == "q" {
+			if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				quality = q
+			}
+		}
+
+		qualities[name] = quality
+	}
+
+	var best CompressionEncoding
+	bestQuality := 0.0
+	for _, encoding := range encodings {
+		quality, ok := qualities[encoding.Name]
+		if !ok {
+			quality, ok = qualities["*"]
+		}
+
+		if ok && quality > bestQuality {
+			best = encoding
+			bestQuality = quality
+		}
+	}
+
+	return best, bestQuality > 0
+}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/blakewilliams/amaro/httprouter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompress(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	router.Use(Compress[httprouter.RequestContext](CompressConfig{
+		Encodings: []CompressionEncoding{GzipEncoding(gzip.BestSpeed), DeflateEncoding(flate.BestSpeed)},
+	}))
+	router.Use(ErrorHandler(logger, func(ctx context.Context, r httprouter.RequestContext, err any) {
+		r.Response().Header().Set("Content-Type", "text/html")
+		r.Response().WriteHeader(http.StatusInternalServerError)
+		_, _ = r.Response().Write([]byte(strings.Repeat("error ", 500)))
+	}))
+
+	large := strings.Repeat("hello world ", 200)
+	router.Get("/large", func(ctx context.Context, r httprouter.RequestContext) {
+		_, _ = r.Response().Write([]byte(large))
+	})
+	router.Get("/small", func(ctx context.Context, r httprouter.RequestContext) {
+		_, _ = r.Response().Write([]byte("hello world"))
+	})
+	router.Get("/image", func(ctx context.Context, r httprouter.RequestContext) {
+		r.Response().Header().Set("Content-Type", "image/png")
+		_, _ = r.Response().Write([]byte(large))
+	})
+	router.Get("/panic", func(ctx context.Context, r httprouter.RequestContext) {
+		_, _ = r.Response().Write([]byte("partial"))
+		panic("omg")
+	})
+
+	serve := func(path string, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+
+		return res
+	}
+
+	gunzip := func(b []byte) string {
+		gr, err := gzip.NewReader(bytes.NewReader(b))
+		require.NoError(t, err)
+		decoded, err := io.ReadAll(gr)
+		require.NoError(t, err)
+
+		return string(decoded)
+	}
+
+	res := serve("/large", "gzip, deflate")
+	require.Equal(t, "gzip", res.Header().Get("Content-Encoding"))
+	require.Equal(t, "Accept-Encoding", res.Header().Get("Vary"))
+	require.Equal(t, "text/plain; charset=utf-8", res.Header().Get("Content-Type"))
+	require.Less(t, res.Body.Len(), len(large))
+	require.Equal(t, large, gunzip(res.Body.Bytes()))
+
+	res = serve("/large", "gzip;q=0.5, deflate")
+	require.Equal(t, "deflate", res.Header().Get("Content-Encoding"))
+	decoded, err := io.ReadAll(flate.NewReader(res.Body))
+	require.NoError(t, err)
+	require.Equal(t, large, string(decoded))
+
+	res = serve("/large", "*")
+	require.Equal(t, "gzip", res.Header().Get("Content-Encoding"))
+
+	res = serve("/large", "gzip;q=0, br")
+	require.Empty(t, res.Header().Get("Content-Encoding"))
+	require.Equal(t, large, res.Body.String())
+
+	res = serve("/large", "")
+	require.Empty(t, res.Header().Get("Content-Encoding"))
+	require.Equal(t, "Accept-Encoding", res.Header().Get("Vary"))
+
+	res = serve("/small", "gzip")
+	require.Empty(t, res.Header().Get("Content-Encoding"))
+	require.Equal(t, "hello world", res.Body.String())
+
+	res = serve("/image", "gzip")
+	require.Empty(t, res.Header().Get("Content-Encoding"))
+	require.Empty(t, res.Header().Get("Vary"))
+
+	res = serve("/panic", "gzip")
+	require.Equal(t, http.StatusInternalServerError, res.Code)
+	require.Equal(t, "gzip", res.Header().Get("Content-Encoding"))
+	require.Equal(t, strings.Repeat("error ", 500), gunzip(res.Body.Bytes()))
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	encodings := []CompressionEncoding{{Name: "br"}, {Name: "gzip"}}
+
+	tests := map[string]string{
+		"":                        "",
+		"identity":                "",
+		"gzip":                    "gzip",
+		"gzip, br":                "br",
+		"GZIP;q=1.0, br;q=0.8":    "gzip",
+		"*;q=0.1, gzip;q=0":       "br",
+		"br;q=0, gzip;q=0":        "",
+		"deflate, gzip;q=invalid": "gzip",
+	}
+
+	for acceptEncoding, want := range tests {
+		encoding, ok := negotiateEncoding(acceptEncoding, encodings)
+		require.Equal(t, want != "", ok, acceptEncoding)
+		require.Equal(t, want, encoding.Name, acceptEncoding)
+	}
+}
+
+func TestCompress_InvalidConfig(t *testing.T) {
+	require.Panics(t, func() { GzipEncoding(42) })
+	require.Panics(t, func() { DeflateEncoding(-3) })
+	require.Panics(t, func() {
+		Compress[httprouter.RequestContext](CompressConfig{
+			Encodings: []CompressionEncoding{{Name: "br"}},
+		})
+	})
+
+	require.NotPanics(t, func() { GzipEncoding(gzip.BestSpeed) })
+	require.NotPanics(t, func() { DeflateEncoding(flate.BestCompression) })
+}
@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blakewilliams/amaro/httprouter"
+)
+
+// CORSConfig configures the CORS middleware.
+type CORSConfig struct {
+	// AllowedOrigins are the origins allowed to make cross-origin requests.
+	// Origins can be exact, e.g. `https://example.com`, contain a wildcard,
+	// e.g. `https://*.example.com`, or be `*` to allow any origin.
+	AllowedOrigins []string
+	// AllowOrigin is called for origins that don't match AllowedOrigins and
+	// allows the origin when it returns true.
+	AllowOrigin func(origin string) bool
+	// AllowedMethods are the methods allowed in cross-origin requests.
+	// Defaults to GET, HEAD, POST, PUT, PATCH, and DELETE.
+	AllowedMethods []string
+	// AllowedHeaders are the request headers allowed in cross-origin
+	// requests. When empty, the headers requested by the preflight request
+	// are allowed.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers that browsers expose to
+	// cross-origin requests.
+	ExposedHeaders []string
+	// AllowCredentials allows cookies and other credentials to be sent with
+	// cross-origin requests.
+	AllowCredentials bool
+	// MaxAge is how long browsers can cache the result of a preflight
+	// request. Defaults to unset, which leaves it to the browser.
+	MaxAge time.Duration
+}
+
+// CORS implements Cross-Origin Resource Sharing. Preflight requests are
+// answered directly with a 204 and the rest of the middleware stack and
+// handler are not run. For other requests from allowed origins the CORS
+// headers are added to the response.
+//
+// The router answers preflight requests automatically by running the
+// middleware of the route matching the requested method, so CORS can be
+// registered with Router.Use, Group.Use, or as route middleware without
+// defining OPTIONS routes. Preflight requests for paths or methods without a
+// route aren't answered. CORS should be registered before middleware that
+// reject requests, like authentication, since preflight requests don't include
+// credentials.
+func CORS[T httprouter.RequestContext](config CORSConfig) httprouter.Middleware[T] {
+	if len(config.AllowedMethods) == 0 {
+		config.AllowedMethods = []string{
+			http.MethodGet,
+			http.MethodHead,
+			http.MethodPost,
+			http.MethodPut,
+			http.MethodPatch,
+			http.MethodDelete,
+		}
+	}
+
+	allowAny := false
+	for _, origin := range config.AllowedOrigins {
+		if origin == "*" {
+			allowAny = true
+		}
+	}
+
+	allowedMethods := strings.Join(config.AllowedMethods, ", ")
+	allowedHeaders := strings.Join(config.AllowedHeaders, ", ")
+	exposedHeaders := strings.Join(config.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(config.MaxAge.Seconds()))
+
+	return func(ctx context.Context, rctx T, next httprouter.Handler[T]) {
+		req := rctx.Request()
+		header := rctx.Response().Header()
+
+		origin := req.Header.Get("Origin")
+		// Preflight requests for methods without a route at the path are
+		// passed on, so the router responds with a 404 or an Allow header
+		// without CORS headers.
+		preflight := req.Method == http.MethodOptions &&
+			req.Header.Get("Access-Control-Request-Method") != "" &&
+			rctx.MatchedPath() != ""
+
+		// The response depends on the Origin unless every origin receives `*`
+		if !allowAny || config.AllowCredentials {
+			header.Add("Vary", "Origin")
+		}
+
+		if origin == "" || !corsOriginAllowed(config, origin) {
+			next(ctx, rctx)
+			return
+		}
+
+		if allowAny && !config.AllowCredentials {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			// Browsers reject `*` when credentials are allowed
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+
+		if config.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if exposedHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", exposedHeaders)
+			}
+
+			next(ctx, rctx)
+			return
+		}
+
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		header.Set("Access-Control-Allow-Methods", allowedMethods)
+
+		if allowedHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", allowedHeaders)
+		} else if requested := req.Header.Get("Access-Control-Request-Headers"); requested != "" {
+			header.Set("Access-Control-Allow-Headers", requested)
+		}
+
+		if config.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", maxAge)
+		}
+
+		rctx.Response().WriteHeader(http.StatusNoContent)
+	}
+}
+
+// corsOriginAllowed reports whether the origin matches the allowed origins or
+// the AllowOrigin predicate.
+func corsOriginAllowed(config CORSConfig, origin string) bool {
+	for _, allowed := range config.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+
+		if prefix, suffix, ok := strings.Cut(allowed, "*"); ok {
+			if len(origin) > len(prefix)+len(suffix) &&
+				strings.HasPrefix(strings.ToLower(origin), strings.ToLower(prefix)) &&
+				strings.HasSuffix(strings.ToLower(origin), strings.ToLower(suffix)) {
+				return true
+			}
+		}
+	}
+
+	return config.AllowOrigin != nil && config.AllowOrigin(origin)
+}
@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blakewilliams/amaro/httprouter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORS(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+
+	router.Use(CORS[httprouter.RequestContext](CORSConfig{
+		AllowedOrigins:   []string{"https://example.com", "https://*.example.org"},
+		AllowOrigin:      func(origin string) bool { return strings.HasSuffix(origin, ".test") },
+		AllowedMethods:   []string{http.MethodGet, http.MethodPost},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}))
+
+	var served bool
+	api := router.Group("/api")
+	api.Post("/posts", func(ctx context.Context, r httprouter.RequestContext) {
+		served = true
+		_, _ = r.Response().Write([]byte("created"))
+	})
+
+	tests := map[string]struct {
+		origin  string
+		allowed bool
+	}{
+		"exact":             {origin: "https://example.com", allowed: true},
+		"wildcard":          {origin: "https://api.example.org", allowed: true},
+		"wildcard no match": {origin: "https://example.org", allowed: false},
+		"predicate":         {origin: "http://app.test", allowed: true},
+		"not allowed":       {origin: "https://evil.com", allowed: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			served = false
+			req := httptest.NewRequest(http.MethodOptions, "/api/posts", nil)
+			req.Header.Set("Origin", tc.origin)
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			req.Header.Set("Access-Control-Request-Headers", "Content-Type")
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, http.StatusNoContent, res.Code)
+			require.False(t, served)
+			require.Contains(t, res.Header().Values("Vary"), "Origin")
+
+			if !tc.allowed {
+				require.Empty(t, res.Header().Get("Access-Control-Allow-Origin"))
+				return
+			}
+
+			require.Equal(t, tc.origin, res.Header().Get("Access-Control-Allow-Origin"))
+			require.Equal(t, "true", res.Header().Get("Access-Control-Allow-Credentials"))
+			require.Equal(t, "GET, POST", res.Header().Get("Access-Control-Allow-Methods"))
+			require.Equal(t, "Content-Type", res.Header().Get("Access-Control-Allow-Headers"))
+			require.Equal(t, "600", res.Header().Get("Access-Control-Max-Age"))
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/posts", nil)
+	req.Header.Set("Origin", "https://example.com")
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	require.True(t, served)
+	require.Equal(t, "created", res.Body.String())
+	require.Equal(t, "https://example.com", res.Header().Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "X-Request-ID", res.Header().Get("Access-Control-Expose-Headers"))
+	require.Empty(t, res.Header().Get("Access-Control-Allow-Methods"))
+}
+
+func TestCORS_AnyOrigin(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+	router.Use(CORS[httprouter.RequestContext](CORSConfig{
+		AllowedOrigins: []string{"*"},
+		AllowedHeaders: []string{"Authorization"},
+	}))
+	router.Get("/", func(ctx context.Context, r httprouter.RequestContext) {})
+
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.Header.Set("Origin", "https://example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	req.Header.Set("Access-Control-Request-Headers", "X-Custom")
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusNoContent, res.Code)
+	require.Equal(t, "*", res.Header().Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "Authorization", res.Header().Get("Access-Control-Allow-Headers"))
+	require.Equal(t, "GET, HEAD, POST, PUT, PATCH, DELETE", res.Header().Get("Access-Control-Allow-Methods"))
+	require.Empty(t, res.Header().Get("Access-Control-Max-Age"))
+	require.NotContains(t, res.Header().Values("Vary"), "Origin")
+
+	// Preflight requests for paths without routes aren't answered
+	req = httptest.NewRequest(http.MethodOptions, "/missing", nil)
+	req.Header.Set("Origin", "https://example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusNotFound, res.Code)
+	require.Empty(t, res.Header().Get("Access-Control-Allow-Methods"))
+
+	// Requests without an Origin are untouched
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Empty(t, res.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORS_Group(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+
+	config := CORSConfig{AllowedOrigins: []string{"https://example.com"}}
+	noop := func(ctx context.Context, r httprouter.RequestContext) {}
+
+	api := router.Group("/api")
+	api.Use(CORS[httprouter.RequestContext](config))
+	api.Post("/posts/:id", noop)
+	router.Post("/uploads", noop, CORS[httprouter.RequestContext](config))
+	router.Post("/private", noop)
+
+	preflight := func(path string, method string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set("Origin", "https://example.com")
+		req.Header.Set("Access-Control-Request-Method", method)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+
+		return res
+	}
+
+	// Group and route middleware answer preflight requests without OPTIONS
+	// routes
+	for _, path := range []string{"/api/posts/1", "/uploads"} {
+		res := preflight(path, http.MethodPost)
+		require.Equal(t, http.StatusNoContent, res.Code)
+		require.Equal(t, "https://example.com", res.Header().Get("Access-Control-Allow-Origin"))
+		require.Contains(t, res.Header().Get("Access-Control-Allow-Methods"), http.MethodPost)
+	}
+
+	// Routes without CORS only receive the Allow header
+	res := preflight("/private", http.MethodPost)
+	require.Equal(t, http.StatusNoContent, res.Code)
+	require.Equal(t, "POST, OPTIONS", res.Header().Get("Allow"))
+	require.Empty(t, res.Header().Get("Access-Control-Allow-Origin"))
+
+	// Methods without a route don't run the group middleware
+	res = preflight("/api/posts/1", http.MethodDelete)
+	require.Equal(t, http.StatusNoContent, res.Code)
+	require.Empty(t, res.Header().Get("Access-Control-Allow-Origin"))
+
+	res = preflight("/api/missing", http.MethodPost)
+	require.Equal(t, http.StatusNotFound, res.Code)
+}
@@ -0,0 +1,295 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/blakewilliams/amaro/httprouter"
+)
+
+// devSourceContext is the number of lines shown before and after the line of
+// each stack frame.
+const devSourceContext = 5
+
+type (
+	// DevErrorsConfig configures the DevErrors middleware.
+	DevErrorsConfig[T httprouter.RequestContext] struct {
+		// Environment is the environment the application is running in. The
+		// middleware is only enabled when it is "development".
+		Environment string
+		// Logger logs recovered panics. Defaults to the request logger, see
+		// RequestLogger.
+		Logger *slog.Logger
+		// Session returns the session data of the request so it can be shown
+		// on the error page.
+		Session func(rctx T) any
+	}
+
+	devErrorPage struct {
+		Status  int
+		Title   string
+		Message string
+		Type    string
+		Method  string
+		URL     string
+		Route   string
+		Params  [][2]string
+		Headers [][2]string
+		Session string
+		Frames  []devFrame
+	}
+
+	devFrame struct {
+		Function string
+		File     string
+		Line     int
+		// App is false for frames in the Go runtime and standard library.
+		App    bool
+		Source []devSourceLine
+	}
+
+	devSourceLine struct {
+		Number  int
+		Code    string
+		Current bool
+	}
+)
+
+var devErrorTemplate = template.Must(template.New("dev_error").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>{{ .Type }}: {{ .Message }}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 0; color: #222; }
+header { background: #c52f24; color: #fff; padding: 1rem 2rem; }
+header h1 { margin: 0 0 .5rem; font-size: 1.4rem; }
+header pre { margin: 0; white-space: pre-wrap; }
+section { padding: 0 2rem; }
+table { border-collapse: collapse; }
+td { padding: .2rem 1rem .2rem 0; vertical-align: top; font-family: monospace; }
+.frame { margin-bottom: 1rem; }
+.frame h3 { font-size: .95rem; margin: 0; font-family: monospace; }
+.frame.lib h3 { color: #888; }
+.source { background: #f6f6f6; margin: .3rem 0; padding: .3rem 0; font-family: monospace; font-size: .85rem; }
+.source div { white-space: pre; padding: 0 .5rem; }
+.source .current { background: #fcc; }
+.source span { color: #999; display: inline-block; width: 3rem; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{ .Status }} {{ .Title }} ({{ .Type }})</h1>
+<pre>{{ .Message }}</pre>
+</header>
+<section>
+<h2>Request</h2>
+<table>
+<tr><td>Method</td><td>{{ .Method }}</td></tr>
+<tr><td>URL</td><td>{{ .URL }}</td></tr>
+<tr><td>Route</td><td>{{ if .Route }}{{ .Route }}{{ else }}(none){{ end }}</td></tr>
+</table>
+{{ if .Params }}<h3>Params</h3>
+<table>{{ range .Params }}<tr><td>{{ index . 0 }}</td><td>{{ index . 1 }}</td></tr>{{ end }}</table>{{ end }}
+<h3>Headers</h3>
+<table>{{ range .Headers }}<tr><td>{{ index . 0 }}</td><td>{{ index . 1 }}</td></tr>{{ end }}</table>
+{{ if .Session }}<h3>Session</h3>
+<pre>{{ .Session }}</pre>{{ end }}
+</section>
+<section>
+<h2>Stack trace</h2>
+{{ range .Frames }}<div class="frame{{ if not .App }} lib{{ end }}">
+<h3>{{ .Function }}</h3>
+<div>{{ .File }}:{{ .Line }}</div>
+{{ if .Source }}<div class="source">{{ range .Source }}<div{{ if .Current }} class="current"{{ end }}><span>{{ .Number }}</span>{{ .Code }}</div>{{ end }}</div>{{ end }}
+</div>
+{{ end }}
+</section>
+</body>
+</html>
+`))
+
+// DevErrors renders a detailed error page for recovered panics and errors
+// reported via httprouter.ReportError, including the stack trace with source
+// excerpts, the request headers, params, and session, and the matched route.
+//
+// The page exposes application internals, so the middleware does nothing
+// unless config.Environment is "development". It should be added after
+// ErrorHandler or ErrorRenderer so it handles errors before they do.
+func DevErrors[T httprouter.RequestContext](config DevErrorsConfig[T]) httprouter.Middleware[T] {
+	if config.Environment != "development" {
+		return func(ctx context.Context, rctx T, next httprouter.Handler[T]) {
+			next(ctx, rctx)
+		}
+	}
+
+	return func(ctx context.Context, rctx T, next httprouter.Handler[T]) {
+		httprouter.SetErrorReporter(rctx, func(ctx context.Context, err error) {
+			// Skip runtime.Callers, callers, this function, and ReportError
+			// so the caller reporting the error is first.
+			renderDevError(config, rctx, httprouter.AsHTTPError(err), err, callers(4))
+		})
+
+		defer func() {
+			if rec := recover(); rec != nil {
+				// Skip runtime.Callers, callers, this function, and the
+				// runtime's panic handling so the panic site is first.
+				frames := callers(4)
+				err := recoveredError(rec)
+
+				logger := config.Logger
+				if logger == nil {
+					logger = RequestLogger(rctx)
+				}
+				logger.Error("recovered in middleware", slog.String("error", err.Error()))
+
+				renderDevError(config, rctx, httprouter.NewHTTPError(http.StatusInternalServerError, "", err), rec, frames)
+			}
+		}()
+
+		next(ctx, rctx)
+	}
+}
+
+func renderDevError[T httprouter.RequestContext](config DevErrorsConfig[T], rctx T, httpErr *httprouter.HTTPError, value any, frames []runtime.Frame) {
+	req := rctx.Request()
+
+	// Show the internal cause, since that's what needs debugging
+	message := fmt.Sprint(value)
+	if httpErr.Err != nil && value == error(httpErr) {
+		message = httpErr.Err.Error()
+	}
+
+	page := devErrorPage{
+		Status:  httpErr.Status,
+		Title:   http.StatusText(httpErr.Status),
+		Message: message,
+		Type:    fmt.Sprintf("%T", value),
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Route:   rctx.MatchedPath(),
+	}
+
+	for key, value := range rctx.Params() {
+		page.Params = append(page.Params, [2]string{key, value})
+	}
+	sort.Slice(page.Params, func(i, j int) bool {
+		return page.Params[i][0] < page.Params[j][0]
+	})
+
+	for key, values := range req.Header {
+		page.Headers = append(page.Headers, [2]string{key, strings.Join(values, ", ")})
+	}
+	sort.Slice(page.Headers, func(i, j int) bool {
+		return page.Headers[i][0] < page.Headers[j][0]
+	})
+
+	if config.Session != nil {
+		if session := config.Session(rctx); session != nil {
+			page.Session = fmt.Sprintf("%+v", session)
+		}
+	}
+
+	sources := make(map[string][]string)
+	for _, frame := range frames {
+		page.Frames = append(page.Frames, devFrame{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+			App:      !isStdlibFrame(frame),
+			Source:   sourceExcerpt(sources, frame.File, frame.Line),
+		})
+	}
+
+	rctx.Response().Clear()
+
+	// The page uses inline styles, which a Content-Security-Policy set by
+	// SecurityHeaders would block. It's only rendered in development, so the
+	// policy is removed rather than relaxed.
+	rctx.Response().Header().Del("Content-Security-Policy")
+	rctx.Response().Header().Del("Content-Security-Policy-Report-Only")
+	rctx.Response().Header().Set("Content-Type", "text/html; charset=utf-8")
+	rctx.Response().WriteHeader(httpErr.Status)
+	_ = devErrorTemplate.Execute(rctx.Response(), page)
+}
+
+// callers returns the stack frames of the calling goroutine, skipping the
+// given number of frames.
+func callers(skip int) []runtime.Frame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip, pcs)
+	iter := runtime.CallersFrames(pcs[:n])
+
+	var frames []runtime.Frame
+	for {
+		frame, more := iter.Next()
+		frames = append(frames, frame)
+		if !more {
+			break
+		}
+	}
+
+	return frames
+}
+
+// isStdlibFrame reports whether the frame belongs to the Go runtime or
+// standard library, whose import paths don't contain a dot in their first
+// element, unlike module paths like `github.com/...`.
+func isStdlibFrame(frame runtime.Frame) bool {
+	pkg := frame.Function
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[:i] + strings.SplitN(pkg[i:], ".", 2)[0]
+	} else {
+		pkg = strings.SplitN(pkg, ".", 2)[0]
+	}
+
+	first := strings.SplitN(pkg, "/", 2)[0]
+	return pkg != "main" && !strings.Contains(first, ".")
+}
+
+// sourceExcerpt returns the lines surrounding line in file. Files are read
+// once and cached in sources. Nil is returned when the file can't be read.
+func sourceExcerpt(sources map[string][]string, file string, line int) []devSourceLine {
+	lines, ok := sources[file]
+	if !ok {
+		lines = readLines(file)
+		sources[file] = lines
+	}
+
+	if line < 1 || line > len(lines) {
+		return nil
+	}
+
+	start := max(line-devSourceContext, 1)
+	end := min(line+devSourceContext, len(lines))
+
+	excerpt := make([]devSourceLine, 0, end-start+1)
+	for i := start; i <= end; i++ {
+		excerpt = append(excerpt, devSourceLine{Number: i, Code: lines[i-1], Current: i == line})
+	}
+
+	return excerpt
+}
+
+func readLines(file string) []string {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	return lines
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/blakewilliams/amaro/httprouter"
+	"github.com/stretchr/testify/require"
+)
+
+func newDevErrorsRouter(environment string) *httprouter.Router[httprouter.RequestContext] {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+	router.Use(DevErrors(DevErrorsConfig[httprouter.RequestContext]{
+		Environment: environment,
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Session: func(r httprouter.RequestContext) any {
+			return map[string]string{"user_id": "42"}
+		},
+	}))
+
+	router.Get("/users/:id", func(ctx context.Context, r httprouter.RequestContext) {
+		panic(errors.New("user <script> exploded"))
+	})
+	router.Get("/fallible", httprouter.Fallible(func(ctx context.Context, r httprouter.RequestContext) error {
+		return httprouter.NewHTTPError(http.StatusBadRequest, "bad", errors.New("missing name"))
+	}))
+
+	return router
+}
+
+func TestDevErrors(t *testing.T) {
+	router := newDevErrorsRouter("development")
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1?tab=posts", nil)
+	req.Header.Set("X-Custom", "hello")
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	body := res.Body.String()
+	require.Equal(t, http.StatusInternalServerError, res.Code)
+	require.Equal(t, "text/html; charset=utf-8", res.Header().Get("Content-Type"))
+	require.Contains(t, body, "user &lt;script&gt; exploded")
+	require.Contains(t, body, "*errors.errorString")
+	require.Contains(t, body, "/users/1?tab=posts")
+	require.Contains(t, body, "<td>/users/:id</td>")
+	require.Contains(t, body, "<td>id</td><td>1</td>")
+	require.Contains(t, body, "<td>X-Custom</td><td>hello</td>")
+	require.Contains(t, body, "map[user_id:42]")
+
+	// The panic site is the first frame and includes its source
+	require.Contains(t, body, "dev_errors_test.go:30")
+	require.Contains(t, body, `class="current"><span>30</span>		panic(errors.New(&#34;user &lt;script&gt; exploded&#34;))`)
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/fallible", nil))
+	require.Equal(t, http.StatusBadRequest, res.Code)
+	require.Contains(t, res.Body.String(), "<pre>missing name</pre>")
+}
+
+func TestDevErrors_Disabled(t *testing.T) {
+	router := newDevErrorsRouter("production")
+
+	require.Panics(t, func() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/fallible", nil))
+	require.Equal(t, http.StatusBadRequest, res.Code)
+	require.Equal(t, "bad", res.Body.String())
+}
+
+func TestIsStdlibFrame(t *testing.T) {
+	tests := map[string]bool{
+		"runtime.gopanic":                  true,
+		"net/http.HandlerFunc.ServeHTTP":   true,
+		"main.main":                        false,
+		"github.com/a/b.(*T).Method":       false,
+		"github.com/a/b.init.func1":        false,
+		"example.com/app/internal/web.run": false,
+	}
+
+	for function, expected := range tests {
+		require.Equal(t, expected, isStdlibFrame(runtime.Frame{Function: function}), function)
+	}
+}
+
+func TestDevErrors_SecurityHeaders(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+	router.Use(DevErrors(DevErrorsConfig[httprouter.RequestContext]{
+		Environment: "development",
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}))
+	router.Use(SecurityHeaders[httprouter.RequestContext](SecurityHeadersConfig{
+		ContentSecurityPolicy: NewCSP().Directive("default-src", CSPSelf).Directive("style-src", CSPSelf, CSPNonce),
+	}))
+	router.Get("/", func(ctx context.Context, r httprouter.RequestContext) {
+		panic("boom")
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Equal(t, http.StatusInternalServerError, res.Code)
+	require.Contains(t, res.Body.String(), "<style>")
+	require.Empty(t, res.Header().Get("Content-Security-Policy"))
+	require.Equal(t, "nosniff", res.Header().Get("X-Content-Type-Options"))
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"log/slog"
+	"mime"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/blakewilliams/amaro/httprouter"
+	"github.com/blakewilliams/amaro/trace"
+)
+
+// ErrorRendererConfig configures the ErrorRenderer middleware.
+type ErrorRendererConfig[T httprouter.RequestContext] struct {
+	// Logger logs internal errors and recovered panics. Defaults to the
+	// request logger, see RequestLogger.
+	Logger *slog.Logger
+	// HTML renders errors for requests that accept HTML. The response is
+	// cleared and the status is written before it's called. Defaults to a
+	// minimal page containing the public message.
+	HTML func(ctx context.Context, rctx T, err *httprouter.HTTPError)
+	// JSON renders errors for requests that prefer JSON. The response is
+	// cleared and the status is written before it's called. Defaults to
+	// `{"error":{"status":404,"message":"Not Found"}}`.
+	JSON func(ctx context.Context, rctx T, err *httprouter.HTTPError)
+}
+
+var errorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head><title>{{ .Status }} {{ .Title }}</title></head>
+<body>
+<h1>{{ .Title }}</h1>
+<p>{{ .Message }}</p>
+</body>
+</html>
+`))
+
+// ErrorRenderer renders errors returned from handlers registered with
+// httprouter.Fallible, errors passed to httprouter.ReportError, and recovered
+// panics. Errors are converted using httprouter.AsHTTPError so only the
+// status and public message of an HTTPError are rendered, other errors and
+// panics are rendered as internal server errors.
+//
+// Requests that prefer JSON, via the Accept or Content-Type header, are
+// rendered using config.JSON and all others using config.HTML. Errors with a
+// 5xx status are logged along with their cause, and the stack trace is logged
+// for panics.
+func ErrorRenderer[T httprouter.RequestContext](config ErrorRendererConfig[T]) httprouter.Middleware[T] {
+	if config.HTML == nil {
+		config.HTML = renderHTMLError[T]
+	}
+
+	if config.JSON == nil {
+		config.JSON = renderJSONError[T]
+	}
+
+	render := func(ctx context.Context, rctx T, err *httprouter.HTTPError, attrs ...slog.Attr) {
+		if err.Status >= 500 {
+			logger := config.Logger
+			if logger == nil {
+				logger = RequestLogger(rctx)
+			}
+
+			if err.Err != nil {
+				attrs = append([]slog.Attr{slog.String("error", err.Err.Error())}, attrs...)
+			}
+			logger.LogAttrs(ctx, slog.LevelError, "error rendered", append([]slog.Attr{slog.Int("status", err.Status)}, attrs...)...)
+
+			if span := trace.SpanFromContext(ctx); span != nil {
+				span.RecordError(err)
+			}
+		}
+
+		rctx.Response().Clear()
+		rctx.Response().WriteHeader(err.Status)
+
+		if prefersJSON(rctx.Request()) {
+			config.JSON(ctx, rctx, err)
+		} else {
+			config.HTML(ctx, rctx, err)
+		}
+	}
+
+	return func(ctx context.Context, rctx T, next httprouter.Handler[T]) {
+		httprouter.SetErrorReporter(rctx, func(ctx context.Context, err error) {
+			render(ctx, rctx, httprouter.AsHTTPError(err))
+		})
+
+		defer func() {
+			if rec := recover(); rec != nil {
+				err := httprouter.NewHTTPError(http.StatusInternalServerError, "", recoveredError(rec))
+				render(ctx, rctx, err, slog.Bool("panic", true), slog.String("stack", string(debug.Stack())))
+			}
+		}()
+
+		next(ctx, rctx)
+	}
+}
+
+func renderHTMLError[T httprouter.RequestContext](ctx context.Context, rctx T, err *httprouter.HTTPError) {
+	rctx.Response().Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	_ = errorPage.Execute(rctx.Response(), map[string]any{
+		"Status":  err.Status,
+		"Title":   http.StatusText(err.Status),
+		"Message": err.PublicMessage(),
+	})
+}
+
+func renderJSONError[T httprouter.RequestContext](ctx context.Context, rctx T, err *httprouter.HTTPError) {
+	rctx.Response().Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(rctx.Response()).Encode(map[string]any{
+		"error": map[string]any{
+			"status":  err.Status,
+			"message": err.PublicMessage(),
+		},
+	})
+}
+
+// prefersJSON reports whether JSON is listed before HTML in the Accept header.
+// When neither is listed, requests with a JSON body are assumed to prefer
+// JSON.
+func prefersJSON(req *http.Request) bool {
+	for _, accept := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(accept)
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+
+		switch {
+		case mediaType == "text/html":
+			return false
+		case isJSONMediaType(mediaType):
+			return true
+		}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	return isJSONMediaType(mediaType)
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
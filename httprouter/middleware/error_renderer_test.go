@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blakewilliams/amaro/httprouter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorRenderer(t *testing.T) {
+	var logs bytes.Buffer
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+	router.Use(ErrorRenderer(ErrorRendererConfig[httprouter.RequestContext]{
+		Logger: slog.New(slog.NewTextHandler(&logs, nil)),
+	}))
+
+	router.Get("/missing", httprouter.Fallible(func(ctx context.Context, r httprouter.RequestContext) error {
+		return httprouter.NewHTTPError(http.StatusNotFound, "<b>User</b> not found", errors.New("no rows"))
+	}))
+	router.Get("/broken", httprouter.Fallible(func(ctx context.Context, r httprouter.RequestContext) error {
+		_, _ = r.Response().Write([]byte("partial"))
+		return errors.New("secret database error")
+	}))
+	router.Get("/panic", func(ctx context.Context, r httprouter.RequestContext) {
+		panic("omg")
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	require.Equal(t, http.StatusNotFound, res.Code)
+	require.Equal(t, "text/html; charset=utf-8", res.Header().Get("Content-Type"))
+	require.Contains(t, res.Body.String(), "<h1>Not Found</h1>")
+	require.Contains(t, res.Body.String(), "&lt;b&gt;User&lt;/b&gt; not found")
+	require.NotContains(t, res.Body.String(), "no rows")
+	require.Empty(t, logs.String())
+
+	res = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/broken", nil)
+	req.Header.Set("Accept", "application/json, text/html;q=0.9")
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusInternalServerError, res.Code)
+	require.Equal(t, "application/json", res.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"error":{"status":500,"message":"Internal Server Error"}}`, res.Body.String())
+	require.Contains(t, logs.String(), "error=\"secret database error\"")
+
+	logs.Reset()
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	require.Equal(t, http.StatusInternalServerError, res.Code)
+	require.Contains(t, res.Body.String(), "<h1>Internal Server Error</h1>")
+	require.Contains(t, logs.String(), "error=\"panic: omg\"")
+	require.Contains(t, logs.String(), "panic=true")
+	require.Contains(t, logs.String(), "stack=")
+}
+
+func TestErrorRenderer_CustomRenderers(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+	router.Use(ErrorRenderer(ErrorRendererConfig[httprouter.RequestContext]{
+		HTML: func(ctx context.Context, r httprouter.RequestContext, err *httprouter.HTTPError) {
+			_, _ = r.Response().Write([]byte("html: " + err.PublicMessage()))
+		},
+		JSON: func(ctx context.Context, r httprouter.RequestContext, err *httprouter.HTTPError) {
+			_, _ = r.Response().Write([]byte("json: " + err.PublicMessage()))
+		},
+	}))
+
+	router.Post("/", httprouter.Fallible(func(ctx context.Context, r httprouter.RequestContext) error {
+		return httprouter.NewHTTPError(http.StatusUnprocessableEntity, "Invalid name", nil)
+	}))
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/", nil))
+	require.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	require.Equal(t, "html: Invalid name", res.Body.String())
+
+	res = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	router.ServeHTTP(res, req)
+	require.Equal(t, "json: Invalid name", res.Body.String())
+}
+
+func TestPrefersJSON(t *testing.T) {
+	tests := map[string]bool{
+		"":                                  false,
+		"*/*":                               false,
+		"application/json":                  true,
+		"application/vnd.api+json":          true,
+		"text/html, application/json":       false,
+		"application/json;q=0, text/html":   false,
+		"application/json, text/plain, */*": true,
+	}
+
+	for accept, expected := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", accept)
+		require.Equal(t, expected, prefersJSON(req), accept)
+	}
+}
@@ -6,11 +6,15 @@ import (
 	"runtime/debug"
 
 	"github.com/blakewilliams/amaro/httprouter"
+	"github.com/blakewilliams/amaro/trace"
 )
 
 // ErrorHandler will catch panics in applications and call the provided
 // handler so that an error response can be rendered. It automatically calls
 // `ResponseWriter.Clear` so partial responses aren't written to the client.
+//
+// The panic is recorded on the span in the context when Tracing is used before
+// ErrorHandler.
 func ErrorHandler[T httprouter.RequestContext](
 	log *slog.Logger,
 	handler func(ctx context.Context, rctx T, recovered any),
@@ -18,14 +22,15 @@ func ErrorHandler[T httprouter.RequestContext](
 	return func(ctx context.Context, rctx T, next httprouter.Handler[T]) {
 		defer func() {
 			if rec := recover(); rec != nil {
-				if err, ok := rec.(error); ok {
-					log.Error(
-						"recovered in middleware",
-						slog.String("error", err.Error()),
-						slog.String("stack", string(debug.Stack())),
-					)
-				} else {
-					log.Error("recovered in middleware")
+				err := recoveredError(rec)
+				log.Error(
+					"recovered in middleware",
+					slog.String("error", err.Error()),
+					slog.String("stack", string(debug.Stack())),
+				)
+
+				if span := trace.SpanFromContext(ctx); span != nil {
+					span.RecordError(err)
 				}
 
 				rctx.Response().Clear()
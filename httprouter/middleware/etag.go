@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/blakewilliams/amaro/httprouter"
+)
+
+// ETagConfig configures the ETag middleware.
+type ETagConfig struct {
+	// Weak generates weak ETags, which indicate the response is semantically
+	// equivalent rather than byte for byte identical.
+	Weak bool
+}
+
+// ETag adds an ETag computed from the buffered body to successful GET and
+// HEAD responses and answers conditional requests. When the If-None-Match
+// header matches the ETag, or the If-Modified-Since header is not before the
+// Last-Modified header set by the handler, the body is replaced with a 304 Not
+// Modified response.
+//
+// Handlers can set their own ETag header, which is used instead of computing
+// one. ETag should be used after Compress so unchanged responses aren't
+// compressed.
+func ETag[T httprouter.RequestContext](config ETagConfig) httprouter.Middleware[T] {
+	return func(ctx context.Context, rctx T, next httprouter.Handler[T]) {
+		next(ctx, rctx)
+
+		req := rctx.Request()
+		res := rctx.Response()
+		if (req.Method != http.MethodGet && req.Method != http.MethodHead) || res.Status() != http.StatusOK {
+			return
+		}
+
+		header := res.Header()
+		etag := header.Get("ETag")
+		if etag == "" {
+			etag = computeETag(res.Body(), config.Weak)
+			header.Set("ETag", etag)
+		}
+
+		if !notModified(req, etag, header.Get("Last-Modified")) {
+			return
+		}
+
+		res.Clear()
+		res.WriteHeader(http.StatusNotModified)
+		header.Del("Content-Type")
+		header.Del("Content-Length")
+	}
+}
+
+// computeETag returns a quoted ETag for body.
+func computeETag(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+	etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+
+	if weak {
+		return "W/" + etag
+	}
+
+	return etag
+}
+
+// notModified reports whether the conditional headers of req match the
+// response. If-Modified-Since is ignored when If-None-Match is present.
+func notModified(req *http.Request, etag string, lastModified string) bool {
+	if ifNoneMatch := req.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, etag)
+	}
+
+	ifModifiedSince := req.Header.Get("If-Modified-Since")
+	if ifModifiedSince == "" || lastModified == "" {
+		return false
+	}
+
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+
+	return !modified.Truncate(time.Second).After(since)
+}
+
+// etagMatches reports whether etag matches any ETag in the If-None-Match
+// header using the weak comparison, which ignores the `W/` prefix.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/blakewilliams/amaro/httprouter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETag(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+	router.Use(ETag[httprouter.RequestContext](ETagConfig{}))
+
+	body := `{"status":"ok"}`
+	router.Get("/status", func(ctx context.Context, r httprouter.RequestContext) {
+		r.Response().Header().Set("Content-Type", "application/json")
+		_, _ = r.Response().Write([]byte(body))
+	})
+	router.Post("/status", func(ctx context.Context, r httprouter.RequestContext) {
+		_, _ = r.Response().Write([]byte(body))
+	})
+	router.Get("/custom", func(ctx context.Context, r httprouter.RequestContext) {
+		r.Response().Header().Set("ETag", `"v1"`)
+		_, _ = r.Response().Write([]byte(body))
+	})
+	router.Get("/error", func(ctx context.Context, r httprouter.RequestContext) {
+		r.Response().WriteHeader(http.StatusInternalServerError)
+		_, _ = r.Response().Write([]byte(body))
+	})
+
+	serve := func(method string, path string, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+
+		return res
+	}
+
+	res := serve(http.MethodGet, "/status", "")
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, body, res.Body.String())
+	etag := res.Header().Get("ETag")
+	require.Regexp(t, `^"[A-Za-z0-9_-]+"$`, etag)
+
+	res = serve(http.MethodGet, "/status", etag)
+	require.Equal(t, http.StatusNotModified, res.Code)
+	require.Empty(t, res.Body.String())
+	require.Equal(t, etag, res.Header().Get("ETag"))
+	require.Empty(t, res.Header().Get("Content-Type"))
+
+	res = serve(http.MethodGet, "/status", `"other", W/`+etag)
+	require.Equal(t, http.StatusNotModified, res.Code)
+
+	res = serve(http.MethodGet, "/status", "*")
+	require.Equal(t, http.StatusNotModified, res.Code)
+
+	res = serve(http.MethodGet, "/status", `"other"`)
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, body, res.Body.String())
+
+	res = serve(http.MethodPost, "/status", etag)
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Empty(t, res.Header().Get("ETag"))
+
+	res = serve(http.MethodGet, "/custom", `"v1"`)
+	require.Equal(t, http.StatusNotModified, res.Code)
+	require.Equal(t, `"v1"`, res.Header().Get("ETag"))
+
+	res = serve(http.MethodGet, "/error", "*")
+	require.Equal(t, http.StatusInternalServerError, res.Code)
+	require.Empty(t, res.Header().Get("ETag"))
+}
+
+func TestETag_Weak(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+	router.Use(ETag[httprouter.RequestContext](ETagConfig{Weak: true}))
+	router.Get("/", func(ctx context.Context, r httprouter.RequestContext) {
+		_, _ = r.Response().Write([]byte("hello"))
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	etag := res.Header().Get("ETag")
+	require.Regexp(t, `^W/"[A-Za-z0-9_-]+"$`, etag)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", etag[2:])
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusNotModified, res.Code)
+}
+
+func TestETag_IfModifiedSince(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+	router.Use(ETag[httprouter.RequestContext](ETagConfig{}))
+
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	router.Get("/", func(ctx context.Context, r httprouter.RequestContext) {
+		r.Response().Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		_, _ = r.Response().Write([]byte("hello"))
+	})
+
+	tests := map[string]struct {
+		ifModifiedSince string
+		ifNoneMatch     string
+		want            int
+	}{
+		"same time":            {ifModifiedSince: modified.Format(http.TimeFormat), want: http.StatusNotModified},
+		"later":                {ifModifiedSince: modified.Add(time.Hour).Format(http.TimeFormat), want: http.StatusNotModified},
+		"earlier":              {ifModifiedSince: modified.Add(-time.Hour).Format(http.TimeFormat), want: http.StatusOK},
+		"invalid":              {ifModifiedSince: "yesterday", want: http.StatusOK},
+		"if-none-match wins":   {ifModifiedSince: modified.Format(http.TimeFormat), ifNoneMatch: `"other"`, want: http.StatusOK},
+		"no conditional input": {want: http.StatusOK},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.ifModifiedSince != "" {
+				req.Header.Set("If-Modified-Since", tc.ifModifiedSince)
+			}
+			if tc.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+
+			require.Equal(t, tc.want, res.Code)
+		})
+	}
+}
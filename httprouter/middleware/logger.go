@@ -3,7 +3,9 @@ package middleware
 import (
 	"context"
 	"log/slog"
+	"net"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/blakewilliams/amaro/httprouter"
@@ -33,27 +35,96 @@ func (r *responseStatusTracker) Header() http.Header {
 	return r.ResponseWriter.Header()
 }
 
+// requestLogger is stored in httprouter.Values so handlers can retrieve the
+// logger bound to the request and add attributes to the access log.
+type requestLogger struct {
+	logger *slog.Logger
+
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// Logger logs when each request starts and when it's served. Both logs include
+// the remote IP and user agent, and the served log also includes the status,
+// duration, bytes written, and any attributes added by handlers via
+// AddLogAttrs.
+//
+// A logger bound to the request ID, method, path, and route is made available
+// to the rest of the middleware stack and the handler via RequestLogger.
 func Logger[ReqCtx httprouter.RequestContext](logger *slog.Logger) func(context.Context, ReqCtx, httprouter.Handler[ReqCtx]) {
 	return func(ctx context.Context, rctx ReqCtx, next httprouter.Handler[ReqCtx]) {
 		start := time.Now()
+		req := rctx.Request()
 
-		logger.Info(
-			"request started",
-			slog.String("path", rctx.Request().URL.Path),
-			slog.String("method", rctx.Request().Method),
+		attrs := []any{
+			slog.String("path", req.URL.Path),
+			slog.String("method", req.Method),
 			slog.String("route", rctx.MatchedPath()),
-		)
+		}
+
+		if id := GetRequestID(rctx); id != "" {
+			attrs = append(attrs, slog.String("request_id", id))
+		}
+
+		reqLogger := &requestLogger{logger: logger.With(attrs...)}
+		httprouter.Set(rctx, reqLogger)
+
+		client := []slog.Attr{
+			slog.String("remote_ip", remoteIP(req.RemoteAddr)),
+			slog.String("user_agent", req.UserAgent()),
+		}
+		reqLogger.logger.LogAttrs(ctx, slog.LevelInfo, "request started", client...)
 
 		next(ctx, rctx)
 		finished := time.Since(start)
 
-		logger.Info(
-			"request served",
-			slog.String("path", rctx.Request().URL.Path),
-			slog.String("method", rctx.Request().Method),
-			slog.String("route", rctx.MatchedPath()),
+		reqLogger.mu.Lock()
+		served := make([]slog.Attr, 0, len(reqLogger.attrs)+len(client)+3)
+		served = append(served, reqLogger.attrs...)
+		reqLogger.mu.Unlock()
+
+		served = append(served, client...)
+		served = append(
+			served,
 			slog.Int("status", rctx.Response().Status()),
 			slog.Int64("ms", finished.Milliseconds()),
+			slog.Int("bytes", len(rctx.Response().Body())),
 		)
+
+		reqLogger.logger.LogAttrs(ctx, slog.LevelInfo, "request served", served...)
 	}
 }
+
+// RequestLogger returns the logger bound to the request by the Logger
+// middleware. slog.Default is returned when Logger is not used.
+func RequestLogger(rctx httprouter.RequestContext) *slog.Logger {
+	if reqLogger, ok := httprouter.Get[*requestLogger](rctx); ok {
+		return reqLogger.logger
+	}
+
+	return slog.Default()
+}
+
+// AddLogAttrs adds attributes to the access log written by the Logger
+// middleware once the request is served, e.g. the ID of the current user.
+func AddLogAttrs(rctx httprouter.RequestContext, attrs ...slog.Attr) {
+	reqLogger, ok := httprouter.Get[*requestLogger](rctx)
+	if !ok {
+		return
+	}
+
+	reqLogger.mu.Lock()
+	defer reqLogger.mu.Unlock()
+
+	reqLogger.attrs = append(reqLogger.attrs, attrs...)
+}
+
+// remoteIP returns the IP portion of a RemoteAddr.
+func remoteIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+
+	return host
+}
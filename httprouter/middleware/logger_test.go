@@ -43,3 +43,54 @@ func TestLogger(t *testing.T) {
 	require.Contains(t, resLine, `"path":"/fox"`)
 	require.Contains(t, resLine, `"route":"/:name"`)
 }
+
+func TestLogger_RequestContext(t *testing.T) {
+	var b bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&b, nil))
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+	router.Use(RequestID[httprouter.RequestContext]())
+	router.Use(Logger[httprouter.RequestContext](logger))
+	router.Get("/:name", func(ctx context.Context, r httprouter.RequestContext) {
+		RequestLogger(r).Info("hello")
+		AddLogAttrs(r, slog.String("user", r.Params()["name"]))
+
+		_, _ = r.Response().Write([]byte("hello world"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/fox", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
+	req.Header.Set("User-Agent", "amaro-test")
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	lines := strings.Split(b.String(), "\n")
+	require.Len(t, lines, 4) // Three log lines, empty newline
+
+	reqLine := lines[0]
+	require.Contains(t, reqLine, `"request_id":"abc-123"`)
+	require.Contains(t, reqLine, `"remote_ip":"192.0.2.1"`)
+	require.Contains(t, reqLine, `"user_agent":"amaro-test"`)
+
+	handlerLine := lines[1]
+	require.Contains(t, handlerLine, `"msg":"hello"`)
+	require.Contains(t, handlerLine, `"request_id":"abc-123"`)
+	require.Contains(t, handlerLine, `"route":"/:name"`)
+
+	resLine := lines[2]
+	require.Contains(t, resLine, `"request_id":"abc-123"`)
+	require.Contains(t, resLine, `"user":"fox"`)
+	require.Contains(t, resLine, `"bytes":11`)
+	require.Contains(t, resLine, `"status":200`)
+	require.Contains(t, resLine, `"remote_ip":"192.0.2.1"`)
+	require.Contains(t, resLine, `"user_agent":"amaro-test"`)
+}
+
+func TestRequestLogger_Default(t *testing.T) {
+	rctx := httprouter.NewRequestContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder(), "/", nil)
+
+	require.Equal(t, slog.Default(), RequestLogger(rctx))
+	AddLogAttrs(rctx, slog.String("ignored", "true"))
+}
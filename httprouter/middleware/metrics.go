@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/blakewilliams/amaro/httprouter"
+	"github.com/blakewilliams/amaro/metrics"
+)
+
+// responseSizeBuckets are the histogram buckets, in bytes, used for response
+// sizes.
+var responseSizeBuckets = []float64{100, 1000, 10_000, 100_000, 1_000_000, 10_000_000}
+
+// Metrics records the number of requests, their latency, and their response
+// size in the given registry. Each metric is labeled by method, the matched
+// route pattern, and status:
+//
+//	http_requests_total
+//	http_request_duration_seconds
+//	http_response_size_bytes
+//
+// Requests that don't match a route are labeled with an empty route. The
+// metrics can be exposed using metrics.Registry.Handler.
+func Metrics[T httprouter.RequestContext](registry *metrics.Registry) httprouter.Middleware[T] {
+	labels := []string{"method", "route", "status"}
+
+	requests := registry.Counter("http_requests_total", "Total number of HTTP requests served.", labels...)
+	durations := registry.Histogram("http_request_duration_seconds", "Time spent serving HTTP requests.", nil, labels...)
+	sizes := registry.Histogram("http_response_size_bytes", "Size of HTTP response bodies.", responseSizeBuckets, labels...)
+
+	return func(ctx context.Context, rctx T, next httprouter.Handler[T]) {
+		start := time.Now()
+		next(ctx, rctx)
+		elapsed := time.Since(start)
+
+		method := rctx.Request().Method
+		route := rctx.MatchedPath()
+		status := strconv.Itoa(rctx.Response().Status())
+
+		requests.Inc(method, route, status)
+		durations.Observe(elapsed.Seconds(), method, route, status)
+		sizes.Observe(float64(len(rctx.Response().Body())), method, route, status)
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/blakewilliams/amaro/httprouter"
+	"github.com/blakewilliams/amaro/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+	router.Use(Metrics[httprouter.RequestContext](registry))
+	router.Get("/users/:id", func(ctx context.Context, r httprouter.RequestContext) {
+		_, _ = r.Response().Write([]byte("hello"))
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	res := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := res.Body.String()
+
+	require.True(t, strings.HasPrefix(res.Header().Get("Content-Type"), "text/plain; version=0.0.4"))
+	require.Contains(t, body, "# TYPE http_requests_total counter\n")
+	require.Contains(t, body, `http_requests_total{method="GET",route="/users/:id",status="200"} 2`)
+	require.Contains(t, body, `http_requests_total{method="GET",route="",status="404"} 1`)
+	require.Contains(t, body, "# TYPE http_request_duration_seconds histogram\n")
+	require.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/users/:id",status="200"} 2`)
+	require.Contains(t, body, `http_response_size_bytes_bucket{method="GET",route="/users/:id",status="200",le="100"} 2`)
+	require.Contains(t, body, `http_response_size_bytes_sum{method="GET",route="/users/:id",status="200"} 10`)
+}
@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/blakewilliams/amaro/httprouter"
+	"github.com/blakewilliams/amaro/httprouter/middleware/session"
+)
+
+type (
+	// RateLimitAlgorithm determines how requests are counted against a
+	// RateLimitPolicy.
+	RateLimitAlgorithm int
+
+	// RateLimitPolicy describes how many requests are allowed in a period of
+	// time.
+	RateLimitPolicy struct {
+		// Algorithm is the algorithm used to count requests. Defaults to
+		// TokenBucket.
+		Algorithm RateLimitAlgorithm
+		// Limit is the number of requests allowed per Window. For TokenBucket
+		// it's also the size of the bucket, which determines the burst size.
+		Limit int
+		// Window is the period of time Limit applies to. It must be at least
+		// 1ms.
+		Window time.Duration
+	}
+
+	// RateLimitResult is the outcome of counting a request against a policy.
+	RateLimitResult struct {
+		// Allowed reports whether the request should be served.
+		Allowed bool
+		// Limit is the Limit of the policy.
+		Limit int
+		// Remaining is the number of requests that can be made before requests
+		// are limited.
+		Remaining int
+		// Reset is the time until the full quota is available again.
+		Reset time.Duration
+		// RetryAfter is the time until the next request will be allowed. It's
+		// zero when the request is allowed.
+		RetryAfter time.Duration
+	}
+
+	// RateLimitStore counts requests for a key and applies the policy.
+	// Implementations must be safe for concurrent use.
+	RateLimitStore interface {
+		Take(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error)
+	}
+
+	// RateLimitConfig configures the RateLimit middleware.
+	RateLimitConfig[T httprouter.RequestContext] struct {
+		// Policy is the limit applied to each key.
+		Policy RateLimitPolicy
+		// Store stores the request counts. Defaults to a MemoryRateLimitStore,
+		// which is not shared between processes.
+		Store RateLimitStore
+		// Key returns the key requests are counted by. Requests with an empty
+		// key are not limited. Defaults to KeyByIP.
+		Key func(rctx T) string
+		// Name namespaces the keys in the store so groups sharing a store can
+		// be limited separately. Defaults to a name derived from Policy, so
+		// groups with the same policy share a limit unless Name is set.
+		Name string
+		// OnLimited writes the response when a request is limited. Defaults to
+		// writing a 429 Too Many Requests response.
+		OnLimited func(ctx context.Context, rctx T)
+		// FailOpen serves requests when the store returns an error instead of
+		// panicking with the error.
+		FailOpen bool
+	}
+)
+
+const (
+	// TokenBucket refills a bucket of Limit tokens evenly over the Window. Each
+	// request takes a token, allowing bursts of up to Limit requests.
+	TokenBucket RateLimitAlgorithm = iota
+	// SlidingWindow allows Limit requests in any Window, approximated by
+	// weighting the count of the previous fixed window.
+	SlidingWindow
+)
+
+// String returns the name of the algorithm.
+func (a RateLimitAlgorithm) String() string {
+	switch a {
+	case TokenBucket:
+		return "token_bucket"
+	case SlidingWindow:
+		return "sliding_window"
+	default:
+		return "unknown"
+	}
+}
+
+// RateLimit limits the number of requests made for each key using the
+// configured policy. The `RateLimit-Limit`, `RateLimit-Remaining`, and
+// `RateLimit-Reset` headers are written to every response and `Retry-After`
+// is written when a request is limited.
+//
+// Different limits can be applied to groups of routes by passing RateLimit to
+// the Use method of each group.
+func RateLimit[T httprouter.RequestContext](config RateLimitConfig[T]) httprouter.Middleware[T] {
+	if config.Policy.Limit <= 0 || config.Policy.Window < time.Millisecond {
+		panic("rate limit policy requires a positive Limit and a Window of at least 1ms")
+	}
+
+	if config.Store == nil {
+		config.Store = NewMemoryRateLimitStore()
+	}
+
+	if config.Key == nil {
+		config.Key = KeyByIP[T]()
+	}
+
+	if config.Name == "" {
+		config.Name = fmt.Sprintf("%s:%d:%s", config.Policy.Algorithm, config.Policy.Limit, config.Policy.Window)
+	}
+
+	if config.OnLimited == nil {
+		config.OnLimited = func(ctx context.Context, rctx T) {
+			rctx.Response().Header().Set("Content-Type", "text/plain; charset=utf-8")
+			rctx.Response().WriteHeader(http.StatusTooManyRequests)
+			_, _ = rctx.Response().Write([]byte(http.StatusText(http.StatusTooManyRequests)))
+		}
+	}
+
+	return func(ctx context.Context, rctx T, next httprouter.Handler[T]) {
+		key := config.Key(rctx)
+		if key == "" {
+			next(ctx, rctx)
+			return
+		}
+
+		result, err := config.Store.Take(ctx, "ratelimit:"+config.Name+":"+key, config.Policy)
+		if err != nil {
+			if config.FailOpen {
+				next(ctx, rctx)
+				return
+			}
+
+			panic(fmt.Errorf("could not apply rate limit: %w", err))
+		}
+
+		header := rctx.Response().Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			header.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			config.OnLimited(ctx, rctx)
+			return
+		}
+
+		next(ctx, rctx)
+	}
+}
+
+// KeyByIP counts requests by the IP address of the client.
+func KeyByIP[T httprouter.RequestContext]() func(rctx T) string {
+	return func(rctx T) string {
+		return "ip:" + remoteIP(rctx.Request().RemoteAddr)
+	}
+}
+
+// KeyBySession counts requests by a value from the session data, like the ID
+// of the current user. Requests where key returns an empty string are not
+// limited. session.Middleware must be used before RateLimit.
+func KeyBySession[T session.Persistable[D], D any](key func(D) string) func(rctx T) string {
+	return func(rctx T) string {
+		value := key(rctx.SessionData())
+		if value == "" {
+			return ""
+		}
+
+		return "session:" + value
+	}
+}
+
+// ceilSeconds rounds d up to the nearest second for use in headers.
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimitSweepInterval is how often expired entries are removed from a
+// MemoryRateLimitStore.
+const rateLimitSweepInterval = time.Minute
+
+type (
+	// MemoryRateLimitStore is a RateLimitStore that keeps counts in memory.
+	// Counts are not shared between processes, so RedisRateLimitStore should
+	// be used when running multiple servers.
+	MemoryRateLimitStore struct {
+		mu        sync.Mutex
+		entries   map[string]*rateLimitEntry
+		nextSweep time.Time
+		now       func() time.Time
+	}
+
+	rateLimitEntry struct {
+		expires time.Time
+
+		// Used by TokenBucket
+		tokens float64
+		last   time.Time
+
+		// Used by SlidingWindow
+		window   int64
+		current  int
+		previous int
+	}
+)
+
+var _ RateLimitStore = (*MemoryRateLimitStore)(nil)
+
+// NewMemoryRateLimitStore returns a new MemoryRateLimitStore.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		entries: make(map[string]*rateLimitEntry),
+		now:     time.Now,
+	}
+}
+
+// Take counts a request for key and reports whether it's allowed by policy.
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &rateLimitEntry{tokens: float64(policy.Limit), last: now}
+		s.entries[key] = entry
+	}
+	entry.expires = now.Add(2 * policy.Window)
+
+	if policy.Algorithm == SlidingWindow {
+		return entry.takeSlidingWindow(now, policy), nil
+	}
+
+	return entry.takeTokenBucket(now, policy), nil
+}
+
+// sweep removes entries that haven't been used for longer than their window so
+// the store doesn't grow without bound.
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+
+	for key, entry := range s.entries {
+		if now.After(entry.expires) {
+			delete(s.entries, key)
+		}
+	}
+
+	s.nextSweep = now.Add(rateLimitSweepInterval)
+}
+
+// takeTokenBucket refills the bucket based on the time since the last request
+// and then takes a token if one is available.
+func (e *rateLimitEntry) takeTokenBucket(now time.Time, policy RateLimitPolicy) RateLimitResult {
+	limit := float64(policy.Limit)
+	perToken := float64(policy.Window) / limit
+
+	elapsed := now.Sub(e.last)
+	if elapsed > 0 {
+		e.tokens = math.Min(limit, e.tokens+float64(elapsed)/perToken)
+		e.last = now
+	}
+
+	result := RateLimitResult{Limit: policy.Limit}
+	if e.tokens >= 1 {
+		e.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - e.tokens) * perToken)
+	}
+
+	result.Remaining = int(e.tokens)
+	result.Reset = time.Duration((limit - e.tokens) * perToken)
+
+	return result
+}
+
+// takeSlidingWindow estimates the number of requests in the last Window by
+// weighting the count of the previous fixed window by how much of it overlaps
+// the sliding window, and counts the request if it's under the limit.
+func (e *rateLimitEntry) takeSlidingWindow(now time.Time, policy RateLimitPolicy) RateLimitResult {
+	window := now.UnixNano() / int64(policy.Window)
+	windowStart := time.Unix(0, window*int64(policy.Window))
+
+	switch window - e.window {
+	case 0:
+	case 1:
+		e.previous, e.current = e.current, 0
+	default:
+		e.previous, e.current = 0, 0
+	}
+	e.window = window
+
+	elapsed := float64(now.Sub(windowStart)) / float64(policy.Window)
+	count := float64(e.previous)*(1-elapsed) + float64(e.current)
+
+	result := RateLimitResult{Limit: policy.Limit}
+	if count+1 <= float64(policy.Limit) {
+		e.current++
+		count++
+		result.Allowed = true
+	} else {
+		result.RetryAfter = slidingWindowRetryAfter(e.previous, e.current, policy.Limit, elapsed, policy.Window)
+	}
+
+	result.Remaining = max(0, int(float64(policy.Limit)-count))
+
+	// Requests in the current window stop counting once the next window ends,
+	// requests in the previous window once the current window ends.
+	result.Reset = windowStart.Add(policy.Window).Sub(now)
+	if e.current > 0 {
+		result.Reset += policy.Window
+	}
+
+	return result
+}
+
+// slidingWindowRetryAfter returns how long until a request would be allowed,
+// given that elapsed of the current window has passed.
+func slidingWindowRetryAfter(previous int, current int, limit int, elapsed float64, window time.Duration) time.Duration {
+	allowed := float64(limit - 1)
+
+	// The request fits in the current window once enough of the previous
+	// window has slid out.
+	if current <= limit-1 && previous > 0 {
+		needed := 1 - (allowed-float64(current))/float64(previous)
+		return time.Duration((needed - elapsed) * float64(window))
+	}
+
+	// Otherwise wait until the current window becomes the previous window and
+	// slides out far enough.
+	needed := 1 - allowed/float64(current)
+	return time.Duration((1 - elapsed + needed) * float64(window))
+}
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisRateLimitStore is a RateLimitStore that keeps counts in Redis so that
+// limits are shared between processes. Each request is counted atomically
+// using a Lua script.
+type RedisRateLimitStore struct {
+	client redis.Scripter
+	now    func() time.Time
+}
+
+var _ RateLimitStore = (*RedisRateLimitStore)(nil)
+
+// NewRedisRateLimitStore returns a RedisRateLimitStore using the given client,
+// which can be a *redis.Client or *redis.ClusterClient.
+func NewRedisRateLimitStore(client redis.Scripter) *RedisRateLimitStore {
+	return &RedisRateLimitStore{
+		client: client,
+		now:    time.Now,
+	}
+}
+
+// tokenBucketScript mirrors rateLimitEntry.takeTokenBucket. Times are in
+// milliseconds.
+var tokenBucketScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local per_token = window / limit
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "last")
+local tokens = tonumber(state[1])
+local last = tonumber(state[2])
+if tokens == nil or last == nil then
+	tokens = limit
+	last = now
+end
+
+if now > last then
+	tokens = math.min(limit, tokens + (now - last) / per_token)
+	last = now
+end
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) * per_token)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "last", tostring(last))
+redis.call("PEXPIRE", KEYS[1], window * 2)
+
+return {allowed, math.floor(tokens), math.ceil((limit - tokens) * per_token), retry}
+`)
+
+// slidingWindowScript mirrors rateLimitEntry.takeSlidingWindow. Times are in
+// milliseconds.
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local current_window = math.floor(now / window)
+
+local state = redis.call("HMGET", KEYS[1], "window", "current", "previous")
+local stored_window = tonumber(state[1])
+local current = tonumber(state[2]) or 0
+local previous = tonumber(state[3]) or 0
+
+if stored_window == current_window - 1 then
+	previous = current
+	current = 0
+elseif stored_window ~= current_window then
+	previous = 0
+	current = 0
+end
+
+local elapsed = (now - current_window * window) / window
+local count = previous * (1 - elapsed) + current
+
+local allowed = 0
+local retry = 0
+if count + 1 <= limit then
+	current = current + 1
+	count = count + 1
+	allowed = 1
+elseif current <= limit - 1 and previous > 0 then
+	local needed = 1 - (limit - 1 - current) / previous
+	retry = math.ceil((needed - elapsed) * window)
+else
+	local needed = 1 - (limit - 1) / current
+	retry = math.ceil((1 - elapsed + needed) * window)
+end
+
+redis.call("HSET", KEYS[1], "window", current_window, "current", current, "previous", previous)
+redis.call("PEXPIRE", KEYS[1], window * 2)
+
+local reset = (current_window + 1) * window - now
+if current > 0 then
+	reset = reset + window
+end
+
+return {allowed, math.max(0, math.floor(limit - count)), reset, retry}
+`)
+
+// Take counts a request for key and reports whether it's allowed by policy.
+// The scripts count in milliseconds, so policies with a Window under 1ms are
+// rejected.
+func (s *RedisRateLimitStore) Take(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error) {
+	if policy.Limit <= 0 || policy.Window < time.Millisecond {
+		return RateLimitResult{}, fmt.Errorf("invalid rate limit policy: limit %d, window %s", policy.Limit, policy.Window)
+	}
+
+	script := tokenBucketScript
+	if policy.Algorithm == SlidingWindow {
+		script = slidingWindowScript
+	}
+
+	values, err := script.Run(
+		ctx,
+		s.client,
+		[]string{key},
+		policy.Limit,
+		policy.Window.Milliseconds(),
+		s.now().UnixMilli(),
+	).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("failed to take rate limit from redis: %w", err)
+	}
+
+	if len(values) != 4 {
+		return RateLimitResult{}, fmt.Errorf("unexpected rate limit result from redis: %v", values)
+	}
+
+	return RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      policy.Limit,
+		Remaining:  int(values[1]),
+		Reset:      time.Duration(values[2]) * time.Millisecond,
+		RetryAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRedisRateLimitStore returns a store connected to the Redis server in
+// REDIS_URL, skipping the test when it isn't set or the server can't be
+// reached.
+func newTestRedisRateLimitStore(t *testing.T) (*RedisRateLimitStore, *time.Time) {
+	t.Helper()
+
+	url := os.Getenv("REDIS_URL")
+	if url == "" {
+		t.Skip("REDIS_URL is not set")
+	}
+
+	opts, err := redis.ParseURL(url)
+	require.NoError(t, err)
+
+	client := redis.NewClient(opts)
+	t.Cleanup(func() { _ = client.Close() })
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Skipf("redis is not available: %s", err)
+	}
+
+	keys := []string{"amaro:test:key", "amaro:test:other"}
+	require.NoError(t, client.Del(context.Background(), keys...).Err())
+	t.Cleanup(func() { _ = client.Del(context.Background(), keys...).Err() })
+
+	now := time.Unix(1000, 0)
+	store := NewRedisRateLimitStore(client)
+	store.now = func() time.Time { return now }
+
+	return store, &now
+}
+
+func TestRedisRateLimitStore_TokenBucket(t *testing.T) {
+	store, now := newTestRedisRateLimitStore(t)
+	policy := RateLimitPolicy{Algorithm: TokenBucket, Limit: 3, Window: 3 * time.Second}
+
+	for i := 2; i >= 0; i-- {
+		result, err := store.Take(context.Background(), "amaro:test:key", policy)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, i, result.Remaining)
+	}
+
+	result, err := store.Take(context.Background(), "amaro:test:key", policy)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, time.Second, result.RetryAfter)
+	require.Equal(t, 3*time.Second, result.Reset)
+
+	// A token is refilled every second
+	*now = now.Add(time.Second)
+	result, err = store.Take(context.Background(), "amaro:test:key", policy)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 0, result.Remaining)
+
+	// Other keys are counted separately
+	result, err = store.Take(context.Background(), "amaro:test:other", policy)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 2, result.Remaining)
+
+	// The bucket never holds more than Limit tokens
+	*now = now.Add(time.Hour)
+	result, err = store.Take(context.Background(), "amaro:test:key", policy)
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Remaining)
+}
+
+func TestRedisRateLimitStore_SlidingWindow(t *testing.T) {
+	store, now := newTestRedisRateLimitStore(t)
+	policy := RateLimitPolicy{Algorithm: SlidingWindow, Limit: 4, Window: 10 * time.Second}
+
+	for i := 0; i < 4; i++ {
+		result, err := store.Take(context.Background(), "amaro:test:key", policy)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, 3-i, result.Remaining)
+	}
+
+	result, err := store.Take(context.Background(), "amaro:test:key", policy)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, 20*time.Second, result.Reset)
+	require.Equal(t, 12500*time.Millisecond, result.RetryAfter)
+
+	// Halfway through the next window, half of the previous requests count
+	*now = now.Add(15 * time.Second)
+	result, err = store.Take(context.Background(), "amaro:test:key", policy)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 1, result.Remaining)
+
+	result, err = store.Take(context.Background(), "amaro:test:key", policy)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 0, result.Remaining)
+
+	result, err = store.Take(context.Background(), "amaro:test:key", policy)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, 2500*time.Millisecond, result.RetryAfter)
+
+	// Both windows have passed
+	*now = now.Add(20 * time.Second)
+	result, err = store.Take(context.Background(), "amaro:test:key", policy)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 3, result.Remaining)
+}
+
+func TestRedisRateLimitStore_InvalidPolicy(t *testing.T) {
+	// The policy is rejected before Redis is contacted
+	store := NewRedisRateLimitStore(redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"}))
+
+	_, err := store.Take(context.Background(), "key", RateLimitPolicy{Limit: 1, Window: time.Microsecond})
+	require.ErrorContains(t, err, "invalid rate limit policy")
+}
@@ -0,0 +1,265 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/blakewilliams/amaro/httprouter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRateLimitStore_TokenBucket(t *testing.T) {
+	now := time.Unix(1000, 0)
+	store := NewMemoryRateLimitStore()
+	store.now = func() time.Time { return now }
+
+	policy := RateLimitPolicy{Algorithm: TokenBucket, Limit: 3, Window: 3 * time.Second}
+
+	for i := 2; i >= 0; i-- {
+		result, err := store.Take(context.Background(), "key", policy)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, i, result.Remaining)
+	}
+
+	result, err := store.Take(context.Background(), "key", policy)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, time.Second, result.RetryAfter)
+	require.Equal(t, 3*time.Second, result.Reset)
+
+	// A token is refilled every second
+	now = now.Add(time.Second)
+	result, err = store.Take(context.Background(), "key", policy)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 0, result.Remaining)
+
+	// Other keys are counted separately
+	result, err = store.Take(context.Background(), "other", policy)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 2, result.Remaining)
+
+	// The bucket never holds more than Limit tokens
+	now = now.Add(time.Hour)
+	result, err = store.Take(context.Background(), "key", policy)
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Remaining)
+}
+
+func TestMemoryRateLimitStore_SlidingWindow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	store := NewMemoryRateLimitStore()
+	store.now = func() time.Time { return now }
+
+	policy := RateLimitPolicy{Algorithm: SlidingWindow, Limit: 4, Window: 10 * time.Second}
+
+	for i := 0; i < 4; i++ {
+		result, err := store.Take(context.Background(), "key", policy)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, 3-i, result.Remaining)
+	}
+
+	result, err := store.Take(context.Background(), "key", policy)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, 20*time.Second, result.Reset)
+	// A quarter of the requests must slide out of the next window to fit
+	require.Equal(t, 12500*time.Millisecond, result.RetryAfter)
+
+	// Halfway through the next window, half of the previous requests count
+	now = now.Add(15 * time.Second)
+	result, err = store.Take(context.Background(), "key", policy)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 1, result.Remaining)
+
+	result, err = store.Take(context.Background(), "key", policy)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 0, result.Remaining)
+
+	result, err = store.Take(context.Background(), "key", policy)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, 2500*time.Millisecond, result.RetryAfter)
+
+	// Both windows have passed
+	now = now.Add(20 * time.Second)
+	result, err = store.Take(context.Background(), "key", policy)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 3, result.Remaining)
+}
+
+func TestMemoryRateLimitStore_Sweep(t *testing.T) {
+	now := time.Unix(1000, 0)
+	store := NewMemoryRateLimitStore()
+	store.now = func() time.Time { return now }
+
+	policy := RateLimitPolicy{Limit: 1, Window: time.Second}
+	_, _ = store.Take(context.Background(), "a", policy)
+	require.Len(t, store.entries, 1)
+
+	now = now.Add(2 * time.Minute)
+	_, _ = store.Take(context.Background(), "b", policy)
+	require.Len(t, store.entries, 1)
+	require.Contains(t, store.entries, "b")
+}
+
+func TestRateLimit(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+
+	router.Use(RateLimit(RateLimitConfig[httprouter.RequestContext]{
+		Policy: RateLimitPolicy{Limit: 2, Window: time.Minute},
+	}))
+
+	router.Get("/", func(ctx context.Context, r httprouter.RequestContext) {
+		_, _ = r.Response().Write([]byte("ok"))
+	})
+
+	api := router.Group("/api")
+	api.Use(RateLimit(RateLimitConfig[httprouter.RequestContext]{
+		Policy: RateLimitPolicy{Algorithm: SlidingWindow, Limit: 1, Window: time.Minute},
+		Key: func(r httprouter.RequestContext) string {
+			return r.Request().Header.Get("Authorization")
+		},
+	}))
+	api.Get("/status", func(ctx context.Context, r httprouter.RequestContext) {
+		_, _ = r.Response().Write([]byte("ok"))
+	})
+
+	serve := func(path string, remoteAddr string, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+
+		return res
+	}
+
+	res := serve("/", "10.0.0.1:1234", "")
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "2", res.Header().Get("RateLimit-Limit"))
+	require.Equal(t, "1", res.Header().Get("RateLimit-Remaining"))
+	require.Equal(t, "30", res.Header().Get("RateLimit-Reset"))
+
+	// Different ports of the same IP share a limit
+	res = serve("/", "10.0.0.1:5678", "")
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "0", res.Header().Get("RateLimit-Remaining"))
+
+	res = serve("/", "10.0.0.1:1234", "")
+	require.Equal(t, http.StatusTooManyRequests, res.Code)
+	require.Equal(t, "30", res.Header().Get("Retry-After"))
+	require.Equal(t, "Too Many Requests", res.Body.String())
+
+	res = serve("/", "10.0.0.2:1234", "")
+	require.Equal(t, http.StatusOK, res.Code)
+
+	// The group limit applies in addition to the router limit, keyed by token
+	res = serve("/api/status", "10.0.0.3:1234", "a")
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "1", res.Header().Get("RateLimit-Limit"))
+
+	res = serve("/api/status", "10.0.0.3:1234", "a")
+	require.Equal(t, http.StatusTooManyRequests, res.Code)
+
+	res = serve("/api/status", "10.0.0.4:1234", "b")
+	require.Equal(t, http.StatusOK, res.Code)
+
+	// Requests without a key are not limited by the group
+	res = serve("/api/status", "10.0.0.5:1234", "")
+	require.Equal(t, http.StatusOK, res.Code)
+	res = serve("/api/status", "10.0.0.5:1234", "")
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, "2", res.Header().Get("RateLimit-Limit"))
+}
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(context.Context, string, RateLimitPolicy) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("store unavailable")
+}
+
+func TestRateLimit_StoreError(t *testing.T) {
+	for _, failOpen := range []bool{true, false} {
+		router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+			return r
+		})
+
+		var recovered any
+		router.Use(func(ctx context.Context, r httprouter.RequestContext, next httprouter.Handler[httprouter.RequestContext]) {
+			defer func() { recovered = recover() }()
+			next(ctx, r)
+		})
+		router.Use(RateLimit(RateLimitConfig[httprouter.RequestContext]{
+			Policy:   RateLimitPolicy{Limit: 1, Window: time.Minute},
+			Store:    failingRateLimitStore{},
+			FailOpen: failOpen,
+		}))
+
+		var served bool
+		router.Get("/", func(ctx context.Context, r httprouter.RequestContext) {
+			served = true
+		})
+
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		require.Equal(t, failOpen, served)
+		if failOpen {
+			require.Nil(t, recovered)
+		} else {
+			require.ErrorContains(t, recovered.(error), "store unavailable")
+		}
+	}
+}
+
+type rateLimitSessionData struct {
+	UserID string
+}
+
+type rateLimitRequestContext struct {
+	httprouter.RequestContext
+	data *rateLimitSessionData
+}
+
+func (r *rateLimitRequestContext) SetSessionData(data *rateLimitSessionData) { r.data = data }
+func (r *rateLimitRequestContext) SessionData() *rateLimitSessionData        { return r.data }
+
+func TestKeyBySession(t *testing.T) {
+	key := KeyBySession[*rateLimitRequestContext](func(data *rateLimitSessionData) string {
+		return data.UserID
+	})
+
+	rctx := &rateLimitRequestContext{data: &rateLimitSessionData{}}
+	require.Equal(t, "", key(rctx))
+
+	rctx.data.UserID = "42"
+	require.Equal(t, "session:42", key(rctx))
+}
+
+func TestRateLimit_InvalidPolicy(t *testing.T) {
+	require.Panics(t, func() {
+		RateLimit(RateLimitConfig[httprouter.RequestContext]{
+			Policy: RateLimitPolicy{Limit: 10, Window: time.Microsecond},
+		})
+	})
+
+	require.Panics(t, func() {
+		RateLimit(RateLimitConfig[httprouter.RequestContext]{
+			Policy: RateLimitPolicy{Window: time.Second},
+		})
+	})
+}
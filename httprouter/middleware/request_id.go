@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/blakewilliams/amaro/httprouter"
+)
+
+// RequestIDHeader is the header used to read and write the request ID.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the longest incoming request ID that will be honored.
+const maxRequestIDLength = 128
+
+// requestID is the type used to store the request ID in httprouter.Values.
+type requestID string
+
+// RequestID assigns an ID to each request so that logs can be correlated
+// across services. The ID is read from the X-Request-ID header when present
+// and valid, otherwise a random ID is generated. The ID is written to the
+// X-Request-ID response header and can be retrieved via GetRequestID.
+//
+// RequestID should be used before Logger so the ID is included in the logs.
+func RequestID[T httprouter.RequestContext]() httprouter.Middleware[T] {
+	return func(ctx context.Context, rctx T, next httprouter.Handler[T]) {
+		id := rctx.Request().Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		httprouter.Set(rctx, requestID(id))
+		rctx.Response().Header().Set(RequestIDHeader, id)
+
+		next(ctx, rctx)
+	}
+}
+
+// GetRequestID returns the ID assigned to the request by the RequestID
+// middleware, or an empty string if none was assigned.
+func GetRequestID(rctx httprouter.RequestContext) string {
+	id, _ := httprouter.Get[requestID](rctx)
+	return string(id)
+}
+
+// validRequestID reports whether an incoming request ID is safe to log and
+// echo back to the client.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(b)
+}
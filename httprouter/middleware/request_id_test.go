@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/blakewilliams/amaro/httprouter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+	router.Use(RequestID[httprouter.RequestContext]())
+
+	var id string
+	router.Get("/", func(ctx context.Context, r httprouter.RequestContext) {
+		id = GetRequestID(r)
+	})
+
+	tests := map[string]struct {
+		header string
+		keep   bool
+	}{
+		"generated":   {header: "", keep: false},
+		"honored":     {header: "abc-123", keep: true},
+		"too long":    {header: strings.Repeat("a", 129), keep: false},
+		"invalid":     {header: "abc 123", keep: false},
+		"non-ascii":   {header: "abcé", keep: false},
+		"max length":  {header: strings.Repeat("a", 128), keep: true},
+		"punctuation": {header: "req:1/2.3_4", keep: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				req.Header.Set(RequestIDHeader, tc.header)
+			}
+			res := httptest.NewRecorder()
+
+			router.ServeHTTP(res, req)
+
+			require.NotEmpty(t, id)
+			require.Equal(t, id, res.Header().Get(RequestIDHeader))
+
+			if tc.keep {
+				require.Equal(t, tc.header, id)
+			} else {
+				require.NotEqual(t, tc.header, id)
+				require.Len(t, id, 32)
+			}
+		})
+	}
+}
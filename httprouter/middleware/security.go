@@ -0,0 +1,247 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blakewilliams/amaro/httprouter"
+)
+
+// Common Content-Security-Policy sources.
+const (
+	CSPSelf          = "'self'"
+	CSPNone          = "'none'"
+	CSPUnsafeInline  = "'unsafe-inline'"
+	CSPStrictDynamic = "'strict-dynamic'"
+	// CSPNonce is replaced with the nonce generated for each request, e.g.
+	// `'nonce-abc123'`. See GetCSPNonce.
+	CSPNonce = "'nonce'"
+)
+
+type (
+	// SecurityHeadersConfig configures the SecurityHeaders middleware.
+	SecurityHeadersConfig struct {
+		// HSTSMaxAge enables Strict-Transport-Security when set. It should
+		// only be set when the application is always served over HTTPS.
+		HSTSMaxAge time.Duration
+		// HSTSIncludeSubdomains applies HSTS to all subdomains.
+		HSTSIncludeSubdomains bool
+		// HSTSPreload allows the domain to be included in browser preload
+		// lists.
+		HSTSPreload bool
+		// ReferrerPolicy is the Referrer-Policy header. Defaults to
+		// `strict-origin-when-cross-origin`.
+		ReferrerPolicy string
+		// FrameOptions is the X-Frame-Options header. Defaults to `DENY`.
+		FrameOptions string
+		// ContentSecurityPolicy is written to the Content-Security-Policy
+		// header when set.
+		ContentSecurityPolicy *CSP
+		// CSPReportOnly writes the policy to the
+		// Content-Security-Policy-Report-Only header instead, so violations
+		// are reported without being blocked.
+		CSPReportOnly bool
+	}
+
+	// CSP builds a Content-Security-Policy. Directives are written in the
+	// order they're added.
+	CSP struct {
+		directives []cspDirective
+		usesNonce  bool
+	}
+
+	cspDirective struct {
+		name    string
+		sources []string
+	}
+
+	// cspNonce is the type used to store the nonce in httprouter.Values.
+	cspNonce string
+)
+
+// SecurityHeaders sets common security headers on every response:
+// X-Content-Type-Options, Referrer-Policy, X-Frame-Options, and optionally
+// Strict-Transport-Security and Content-Security-Policy.
+//
+// When the policy uses CSPNonce, a nonce is generated for each request and can
+// be retrieved with GetCSPNonce so templates can add it to inline scripts and
+// styles.
+func SecurityHeaders[T httprouter.RequestContext](config SecurityHeadersConfig) httprouter.Middleware[T] {
+	if config.ReferrerPolicy == "" {
+		config.ReferrerPolicy = "strict-origin-when-cross-origin"
+	}
+
+	if config.FrameOptions == "" {
+		config.FrameOptions = "DENY"
+	}
+
+	var hsts string
+	if config.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(config.HSTSMaxAge.Seconds()))
+		if config.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if config.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+
+	cspHeader := "Content-Security-Policy"
+	if config.CSPReportOnly {
+		cspHeader = "Content-Security-Policy-Report-Only"
+	}
+
+	var policy string
+	if config.ContentSecurityPolicy != nil && !config.ContentSecurityPolicy.usesNonce {
+		policy = config.ContentSecurityPolicy.String("")
+	}
+
+	return func(ctx context.Context, rctx T, next httprouter.Handler[T]) {
+		header := rctx.Response().Header()
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("Referrer-Policy", config.ReferrerPolicy)
+		header.Set("X-Frame-Options", config.FrameOptions)
+
+		if hsts != "" {
+			header.Set("Strict-Transport-Security", hsts)
+		}
+
+		if config.ContentSecurityPolicy != nil {
+			if config.ContentSecurityPolicy.usesNonce {
+				nonce := newCSPNonce()
+				httprouter.Set(rctx, cspNonce(nonce))
+				header.Set(cspHeader, config.ContentSecurityPolicy.String(nonce))
+			} else {
+				header.Set(cspHeader, policy)
+			}
+		}
+
+		next(ctx, rctx)
+	}
+}
+
+// GetCSPNonce returns the nonce generated for the request by SecurityHeaders,
+// or an empty string if the policy doesn't use CSPNonce. Templates should
+// render it in the nonce attribute of inline script and style tags:
+//
+//	<script nonce="{{ CSPNonce }}">...</script>
+func GetCSPNonce(rctx httprouter.RequestContext) string {
+	nonce, _ := httprouter.Get[cspNonce](rctx)
+	return string(nonce)
+}
+
+// NewCSP returns an empty Content-Security-Policy builder.
+func NewCSP() *CSP {
+	return &CSP{}
+}
+
+// Directive adds a directive with the given sources to the policy, e.g.
+// `Directive("upgrade-insecure-requests")`. Sources are appended when the
+// directive was already added.
+func (c *CSP) Directive(name string, sources ...string) *CSP {
+	for _, source := range sources {
+		if source == CSPNonce {
+			c.usesNonce = true
+		}
+	}
+
+	for i, directive := range c.directives {
+		if directive.name == name {
+			c.directives[i].sources = append(directive.sources, sources...)
+			return c
+		}
+	}
+
+	c.directives = append(c.directives, cspDirective{name: name, sources: sources})
+	return c
+}
+
+// DefaultSrc adds sources to the default-src directive.
+func (c *CSP) DefaultSrc(sources ...string) *CSP {
+	return c.Directive("default-src", sources...)
+}
+
+// ScriptSrc adds sources to the script-src directive.
+func (c *CSP) ScriptSrc(sources ...string) *CSP {
+	return c.Directive("script-src", sources...)
+}
+
+// StyleSrc adds sources to the style-src directive.
+func (c *CSP) StyleSrc(sources ...string) *CSP {
+	return c.Directive("style-src", sources...)
+}
+
+// ImgSrc adds sources to the img-src directive.
+func (c *CSP) ImgSrc(sources ...string) *CSP {
+	return c.Directive("img-src", sources...)
+}
+
+// ConnectSrc adds sources to the connect-src directive.
+func (c *CSP) ConnectSrc(sources ...string) *CSP {
+	return c.Directive("connect-src", sources...)
+}
+
+// FontSrc adds sources to the font-src directive.
+func (c *CSP) FontSrc(sources ...string) *CSP {
+	return c.Directive("font-src", sources...)
+}
+
+// ObjectSrc adds sources to the object-src directive.
+func (c *CSP) ObjectSrc(sources ...string) *CSP {
+	return c.Directive("object-src", sources...)
+}
+
+// FrameAncestors adds sources to the frame-ancestors directive.
+func (c *CSP) FrameAncestors(sources ...string) *CSP {
+	return c.Directive("frame-ancestors", sources...)
+}
+
+// BaseURI adds sources to the base-uri directive.
+func (c *CSP) BaseURI(sources ...string) *CSP {
+	return c.Directive("base-uri", sources...)
+}
+
+// FormAction adds sources to the form-action directive.
+func (c *CSP) FormAction(sources ...string) *CSP {
+	return c.Directive("form-action", sources...)
+}
+
+// ReportTo sets the reporting endpoint group violations are reported to.
+func (c *CSP) ReportTo(group string) *CSP {
+	return c.Directive("report-to", group)
+}
+
+// String returns the policy with CSPNonce replaced by the given nonce.
+func (c *CSP) String(nonce string) string {
+	var b strings.Builder
+	for i, directive := range c.directives {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+
+		b.WriteString(directive.name)
+		for _, source := range directive.sources {
+			if source == CSPNonce {
+				source = "'nonce-" + nonce + "'"
+			}
+
+			b.WriteByte(' ')
+			b.WriteString(source)
+		}
+	}
+
+	return b.String()
+}
+
+func newCSPNonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(b)
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blakewilliams/amaro/httprouter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+	router.Use(SecurityHeaders[httprouter.RequestContext](SecurityHeadersConfig{
+		HSTSMaxAge:            365 * 24 * time.Hour,
+		HSTSIncludeSubdomains: true,
+		ContentSecurityPolicy: NewCSP().
+			DefaultSrc(CSPSelf).
+			ScriptSrc(CSPSelf, CSPNonce).
+			ObjectSrc(CSPNone),
+	}))
+
+	var nonce string
+	router.Get("/", func(ctx context.Context, r httprouter.RequestContext) {
+		nonce = GetCSPNonce(r)
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.NotEmpty(t, nonce)
+	require.Equal(t, "nosniff", res.Header().Get("X-Content-Type-Options"))
+	require.Equal(t, "strict-origin-when-cross-origin", res.Header().Get("Referrer-Policy"))
+	require.Equal(t, "DENY", res.Header().Get("X-Frame-Options"))
+	require.Equal(t, "max-age=31536000; includeSubDomains", res.Header().Get("Strict-Transport-Security"))
+	require.Equal(
+		t,
+		"default-src 'self'; script-src 'self' 'nonce-"+nonce+"'; object-src 'none'",
+		res.Header().Get("Content-Security-Policy"),
+	)
+
+	firstNonce := nonce
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NotEqual(t, firstNonce, nonce)
+	require.True(t, strings.Contains(res.Header().Get("Content-Security-Policy"), nonce))
+}
+
+func TestSecurityHeaders_Config(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+	router.Use(SecurityHeaders[httprouter.RequestContext](SecurityHeadersConfig{
+		ReferrerPolicy:        "no-referrer",
+		FrameOptions:          "SAMEORIGIN",
+		ContentSecurityPolicy: NewCSP().DefaultSrc(CSPSelf).Directive("upgrade-insecure-requests").DefaultSrc("https:"),
+		CSPReportOnly:         true,
+	}))
+
+	var nonce string
+	router.Get("/", func(ctx context.Context, r httprouter.RequestContext) {
+		nonce = GetCSPNonce(r)
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Empty(t, nonce)
+	require.Equal(t, "no-referrer", res.Header().Get("Referrer-Policy"))
+	require.Equal(t, "SAMEORIGIN", res.Header().Get("X-Frame-Options"))
+	require.Empty(t, res.Header().Get("Strict-Transport-Security"))
+	require.Empty(t, res.Header().Get("Content-Security-Policy"))
+	require.Equal(
+		t,
+		"default-src 'self' https:; upgrade-insecure-requests",
+		res.Header().Get("Content-Security-Policy-Report-Only"),
+	)
+}
@@ -0,0 +1,305 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrSessionNotFound is returned by a Backend when a session doesn't exist or
+// has expired.
+var ErrSessionNotFound = errors.New("session not found")
+
+// Backend stores session data server-side for stores created with
+// NewServerSide. Only the signed session ID is stored in the cookie, so
+// sessions aren't limited by the size of cookies and can be revoked by
+// deleting them.
+type Backend interface {
+	// Load returns the data of the session with the given ID or
+	// ErrSessionNotFound.
+	Load(ctx context.Context, id string) ([]byte, error)
+	// Save stores the session data, expiring it after ttl.
+	Save(ctx context.Context, id string, data []byte, ttl time.Duration) error
+	// Touch extends the expiration of the session to ttl from now without
+	// changing its data. Touching a session that doesn't exist does nothing,
+	// so a session destroyed by a concurrent request isn't recreated.
+	Touch(ctx context.Context, id string, ttl time.Duration) error
+	// Destroy deletes the session. Destroying a session that doesn't exist
+	// is not an error.
+	Destroy(ctx context.Context, id string) error
+}
+
+// MemoryBackend stores sessions in memory. Sessions are lost when the process
+// exits and aren't shared between processes, so it's mostly useful for
+// development and tests.
+type MemoryBackend struct {
+	mu        sync.Mutex
+	sessions  map[string]memorySession
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+type memorySession struct {
+	data    []byte
+	expires time.Time
+}
+
+var _ Backend = (*MemoryBackend)(nil)
+
+// NewMemoryBackend returns an empty MemoryBackend.
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		sessions: make(map[string]memorySession),
+		now:      time.Now,
+	}
+}
+
+// Load implements Backend.
+func (b *MemoryBackend) Load(ctx context.Context, id string) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	session, ok := b.sessions[id]
+	if !ok || !b.now().Before(session.expires) {
+		delete(b.sessions, id)
+		return nil, ErrSessionNotFound
+	}
+
+	return session.data, nil
+}
+
+// Save implements Backend.
+func (b *MemoryBackend) Save(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.sessions[id] = memorySession{data: append([]byte(nil), data...), expires: now.Add(ttl)}
+
+	// Remove expired sessions periodically so abandoned sessions don't
+	// accumulate.
+	if now.Sub(b.lastSweep) >= time.Minute {
+		b.lastSweep = now
+		for id, session := range b.sessions {
+			if !now.Before(session.expires) {
+				delete(b.sessions, id)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Touch implements Backend.
+func (b *MemoryBackend) Touch(ctx context.Context, id string, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	session, ok := b.sessions[id]
+	if !ok || !now.Before(session.expires) {
+		return nil
+	}
+
+	session.expires = now.Add(ttl)
+	b.sessions[id] = session
+
+	return nil
+}
+
+// Destroy implements Backend.
+func (b *MemoryBackend) Destroy(ctx context.Context, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.sessions, id)
+	return nil
+}
+
+// FileBackend stores each session in a file in a directory. Sessions expire
+// their TTL after the modification time of their file, and expired sessions
+// are deleted when they're loaded.
+type FileBackend struct {
+	dir string
+	now func() time.Time
+}
+
+var _ Backend = (*FileBackend)(nil)
+
+// NewFileBackend returns a FileBackend storing sessions in dir, creating it if
+// needed.
+func NewFileBackend(dir string) (*FileBackend, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("could not create session directory: %w", err)
+	}
+
+	return &FileBackend{dir: dir, now: time.Now}, nil
+}
+
+// Load implements Backend.
+func (b *FileBackend) Load(ctx context.Context, id string) ([]byte, error) {
+	path, err := b.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrSessionNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read session: %w", err)
+	}
+	defer f.Close()
+
+	expired, err := b.expired(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not read session: %w", err)
+	} else if expired {
+		_ = os.Remove(path)
+		return nil, ErrSessionNotFound
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not read session: %w", err)
+	}
+
+	return data, nil
+}
+
+// Save implements Backend.
+func (b *FileBackend) Save(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	path, err := b.path(id)
+	if err != nil {
+		return err
+	}
+
+	contents := make([]byte, 8, 8+len(data))
+	binary.BigEndian.PutUint64(contents, uint64(ttl))
+	contents = append(contents, data...)
+
+	// Write to a temporary file and rename it so concurrent requests never
+	// read a partially written session.
+	f, err := os.CreateTemp(b.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("could not write session: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(contents); err != nil {
+		f.Close()
+		return fmt.Errorf("could not write session: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("could not write session: %w", err)
+	}
+
+	now := b.now()
+	if err := os.Chtimes(f.Name(), now, now); err != nil {
+		return fmt.Errorf("could not write session: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("could not write session: %w", err)
+	}
+
+	return nil
+}
+
+// Touch implements Backend. The TTL stored by Save is reused, since the
+// expiration only depends on the modification time of the file.
+func (b *FileBackend) Touch(ctx context.Context, id string, ttl time.Duration) error {
+	path, err := b.path(id)
+	if err != nil {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("could not touch session: %w", err)
+	}
+
+	expired, err := b.expired(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("could not touch session: %w", err)
+	} else if expired {
+		// Sessions that expired but haven't been removed yet must not be
+		// revived.
+		_ = os.Remove(path)
+		return nil
+	}
+
+	now := b.now()
+	if err := os.Chtimes(path, now, now); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not touch session: %w", err)
+	}
+
+	return nil
+}
+
+// expired reads the TTL stored at the start of the session file and reports
+// whether the session has expired. Files too short to hold a TTL are treated
+// as expired.
+func (b *FileBackend) expired(f *os.File) (bool, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	// Files start with the TTL in nanoseconds
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(f, header); errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	ttl := time.Duration(binary.BigEndian.Uint64(header))
+	return !b.now().Before(info.ModTime().Add(ttl)), nil
+}
+
+// Destroy implements Backend.
+func (b *FileBackend) Destroy(ctx context.Context, id string) error {
+	path, err := b.path(id)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not destroy session: %w", err)
+	}
+
+	return nil
+}
+
+// path returns the path of the session file, validating the ID so it can't
+// escape the directory.
+func (b *FileBackend) path(id string) (string, error) {
+	if _, err := hex.DecodeString(id); err != nil || id == "" {
+		return "", ErrSessionNotFound
+	}
+
+	return filepath.Join(b.dir, id+".session"), nil
+}
+
+// newSessionID returns a random session ID.
+func newSessionID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate session ID: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
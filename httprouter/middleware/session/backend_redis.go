@@ -0,0 +1,66 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisBackend stores sessions in Redis, expiring them using key TTLs.
+type RedisBackend struct {
+	client redis.Cmdable
+	// Prefix is prepended to session IDs to build the Redis key. Defaults to
+	// `session:`.
+	Prefix string
+}
+
+var _ Backend = (*RedisBackend)(nil)
+
+// NewRedisBackend returns a RedisBackend using the given client, which can be
+// a *redis.Client or *redis.ClusterClient.
+func NewRedisBackend(client redis.Cmdable) *RedisBackend {
+	return &RedisBackend{client: client, Prefix: "session:"}
+}
+
+// Load implements Backend.
+func (b *RedisBackend) Load(ctx context.Context, id string) ([]byte, error) {
+	data, err := b.client.Get(ctx, b.Prefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrSessionNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("could not load session from redis: %w", err)
+	}
+
+	return data, nil
+}
+
+// Save implements Backend.
+func (b *RedisBackend) Save(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	if err := b.client.Set(ctx, b.Prefix+id, data, ttl).Err(); err != nil {
+		return fmt.Errorf("could not save session to redis: %w", err)
+	}
+
+	return nil
+}
+
+// Touch implements Backend.
+func (b *RedisBackend) Touch(ctx context.Context, id string, ttl time.Duration) error {
+	// EXPIRE does nothing when the key doesn't exist
+	if err := b.client.Expire(ctx, b.Prefix+id, ttl).Err(); err != nil {
+		return fmt.Errorf("could not touch session in redis: %w", err)
+	}
+
+	return nil
+}
+
+// Destroy implements Backend.
+func (b *RedisBackend) Destroy(ctx context.Context, id string) error {
+	if err := b.client.Del(ctx, b.Prefix+id).Err(); err != nil {
+		return fmt.Errorf("could not destroy session in redis: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package session
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisBackend(t *testing.T) {
+	url := os.Getenv("REDIS_URL")
+	if url == "" {
+		t.Skip("REDIS_URL is not set")
+	}
+
+	opts, err := redis.ParseURL(url)
+	require.NoError(t, err)
+
+	client := redis.NewClient(opts)
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("redis is not available: %s", err)
+	}
+
+	backend := NewRedisBackend(client)
+	backend.Prefix = "amaro:test:session:"
+
+	id, err := newSessionID()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Del(ctx, backend.Prefix+id).Err() })
+
+	_, err = backend.Load(ctx, id)
+	require.ErrorIs(t, err, ErrSessionNotFound)
+
+	// Touching a missing session doesn't create it
+	require.NoError(t, backend.Touch(ctx, id, time.Hour))
+	_, err = backend.Load(ctx, id)
+	require.ErrorIs(t, err, ErrSessionNotFound)
+
+	require.NoError(t, backend.Save(ctx, id, []byte(`{"UserID":1}`), time.Minute))
+	data, err := backend.Load(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, `{"UserID":1}`, string(data))
+
+	ttl, err := client.TTL(ctx, backend.Prefix+id).Result()
+	require.NoError(t, err)
+	require.InDelta(t, time.Minute, ttl, float64(5*time.Second))
+
+	require.NoError(t, backend.Touch(ctx, id, time.Hour))
+	ttl, err = client.TTL(ctx, backend.Prefix+id).Result()
+	require.NoError(t, err)
+	require.InDelta(t, time.Hour, ttl, float64(5*time.Second))
+
+	data, err = backend.Load(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, `{"UserID":1}`, string(data))
+
+	require.NoError(t, backend.Destroy(ctx, id))
+	require.NoError(t, backend.Destroy(ctx, id))
+	_, err = backend.Load(ctx, id)
+	require.ErrorIs(t, err, ErrSessionNotFound)
+}
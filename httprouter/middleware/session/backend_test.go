@@ -0,0 +1,75 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackends(t *testing.T) {
+	fileBackend, err := NewFileBackend(t.TempDir())
+	require.NoError(t, err)
+
+	memoryBackend := NewMemoryBackend()
+
+	backends := map[string]struct {
+		backend Backend
+		setNow  func(func() time.Time)
+	}{
+		"memory": {memoryBackend, func(now func() time.Time) { memoryBackend.now = now }},
+		"file":   {fileBackend, func(now func() time.Time) { fileBackend.now = now }},
+	}
+
+	for name, tc := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			id, err := newSessionID()
+			require.NoError(t, err)
+
+			_, err = tc.backend.Load(ctx, id)
+			require.ErrorIs(t, err, ErrSessionNotFound)
+
+			require.NoError(t, tc.backend.Save(ctx, id, []byte(`{"UserID":1}`), time.Hour))
+			data, err := tc.backend.Load(ctx, id)
+			require.NoError(t, err)
+			require.Equal(t, `{"UserID":1}`, string(data))
+
+			tc.setNow(func() time.Time { return time.Now().Add(2 * time.Hour) })
+			_, err = tc.backend.Load(ctx, id)
+			require.ErrorIs(t, err, ErrSessionNotFound)
+			tc.setNow(time.Now)
+
+			require.NoError(t, tc.backend.Save(ctx, id, []byte(`{"UserID":2}`), time.Hour))
+			tc.setNow(func() time.Time { return time.Now().Add(50 * time.Minute) })
+			require.NoError(t, tc.backend.Touch(ctx, id, time.Hour))
+			tc.setNow(func() time.Time { return time.Now().Add(100 * time.Minute) })
+			data, err = tc.backend.Load(ctx, id)
+			require.NoError(t, err)
+			require.Equal(t, `{"UserID":2}`, string(data))
+			tc.setNow(time.Now)
+
+			// Expired sessions can't be revived by touching them
+			tc.setNow(func() time.Time { return time.Now().Add(3 * time.Hour) })
+			require.NoError(t, tc.backend.Touch(ctx, id, time.Hour))
+			_, err = tc.backend.Load(ctx, id)
+			require.ErrorIs(t, err, ErrSessionNotFound)
+			tc.setNow(time.Now)
+
+			require.NoError(t, tc.backend.Destroy(ctx, id))
+			require.NoError(t, tc.backend.Touch(ctx, id, time.Hour))
+			require.NoError(t, tc.backend.Destroy(ctx, id))
+			_, err = tc.backend.Load(ctx, id)
+			require.ErrorIs(t, err, ErrSessionNotFound)
+		})
+	}
+}
+
+func TestFileBackend_InvalidID(t *testing.T) {
+	backend, err := NewFileBackend(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = backend.Load(context.Background(), "../../etc/passwd")
+	require.ErrorIs(t, err, ErrSessionNotFound)
+}
@@ -66,6 +66,15 @@ func Middleware[T CSRFable](config MiddlewareConfig[T]) httprouter.Middleware[T]
 				token = headerToken
 			} else {
 				err := rctx.Request().ParseForm()
+
+				// Bodies over the limit set by middleware.BodyLimit are
+				// rejected as too large rather than as invalid tokens.
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					httprouter.ReportError(ctx, rctx, err)
+					return
+				}
+
 				if err != nil {
 					logger.Error("unable to parse form in csrf middleware", "error", err.Error(), "valid", "false")
 					panic("invalid authenticity token")
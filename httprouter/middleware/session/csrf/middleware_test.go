@@ -2,8 +2,10 @@ package csrf
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/blakewilliams/amaro/httprouter"
@@ -96,6 +98,25 @@ func TestMiddleware(t *testing.T) {
 
 		require.True(t, called)
 	})
+
+	t.Run("reports bodies that are too large", func(t *testing.T) {
+		rctx := newRequestContext(http.MethodPost)
+		rctx.SetCSRF(NewCSRF(WithTokenLength(16)))
+		req := rctx.Request()
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Body = http.MaxBytesReader(rctx.Response(), io.NopCloser(strings.NewReader("authenticity_token=too-long")), 5)
+		called := false
+		next := func(ctx context.Context, rctx *requestContext) {
+			called = true
+		}
+
+		Middleware(MiddlewareConfig[*requestContext]{
+			TokenLength: 16,
+		})(context.Background(), rctx, next)
+
+		require.False(t, called)
+		require.Equal(t, http.StatusRequestEntityTooLarge, rctx.Response().Status())
+	})
 }
 
 func newRequestContext(method string) *requestContext {
@@ -0,0 +1,127 @@
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+// VerifierKey is a Verifier identified by an ID, used by RotatingVerifier.
+type VerifierKey struct {
+	// ID identifies the key in encoded messages. It's public, so it must not
+	// be derived from the secret, and can't contain `--`.
+	ID string
+	// Verifier encodes and decodes messages using the key.
+	Verifier Verifier
+}
+
+// RotatingVerifier is a Verifier that encodes messages using the newest key
+// and decodes messages using any of its keys, so secrets can be rotated
+// without invalidating existing sessions. The key ID is prepended to encoded
+// messages to pick the key used to decode them.
+//
+// To rotate secrets, add a key with the new secret first and remove the
+// oldest key once messages encoded with it have expired:
+//
+//	verifier := session.NewRotatingVerifier(
+//		session.VerifierKey{ID: "2024-06", Verifier: session.NewEncryptedVerifier(session.DeriveKey(newSecret, "session", 32))},
+//		session.VerifierKey{ID: "2024-01", Verifier: session.NewEncryptedVerifier(session.DeriveKey(oldSecret, "session", 32))},
+//	)
+type RotatingVerifier struct {
+	keys []VerifierKey
+}
+
+var _ Verifier = (*RotatingVerifier)(nil)
+
+// NewRotatingVerifier returns a RotatingVerifier using the given keys, newest
+// first. It panics if no keys are given or the IDs are invalid.
+func NewRotatingVerifier(keys ...VerifierKey) RotatingVerifier {
+	if len(keys) == 0 {
+		panic("NewRotatingVerifier requires at least one key")
+	}
+
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key.ID == "" || strings.Contains(key.ID, "--") {
+			panic(fmt.Sprintf("invalid verifier key ID %q", key.ID))
+		}
+
+		if seen[key.ID] {
+			panic(fmt.Sprintf("duplicate verifier key ID %q", key.ID))
+		}
+		seen[key.ID] = true
+	}
+
+	return RotatingVerifier{keys: keys}
+}
+
+// Encode encodes the data using the newest key and prepends its ID.
+func (v RotatingVerifier) Encode(data []byte) (string, error) {
+	key := v.keys[0]
+
+	message, err := key.Verifier.Encode(data)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s--%s", key.ID, message), nil
+}
+
+// Decode decodes the message using the key identified by its ID. Messages
+// without a known key ID, like messages encoded before switching to a
+// RotatingVerifier, are decoded using each key in order.
+func (v RotatingVerifier) Decode(message string) ([]byte, error) {
+	if id, rest, ok := strings.Cut(message, "--"); ok {
+		for _, key := range v.keys {
+			if key.ID == id {
+				return key.Verifier.Decode(rest)
+			}
+		}
+	}
+
+	var err error
+	for _, key := range v.keys {
+		var data []byte
+		data, err = key.Verifier.Decode(message)
+		if err == nil {
+			return data, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Invalid message, no key could decode it: %w", err)
+}
+
+// DeriveKey derives a key of length bytes for the given purpose from secret
+// using HKDF-SHA256 (RFC 5869), so a single secret can be used to create
+// independent keys for sessions, CSRF, signed URLs, etc:
+//
+//	sessionKey := session.DeriveKey(secret, "session", 32)
+//	urlKey := session.DeriveKey(secret, "signed-urls", 32)
+//
+// The secret should have at least as many random bytes as the derived keys.
+// DeriveKey panics if length is larger than 8160 bytes, the limit of HKDF.
+func DeriveKey(secret string, purpose string, length int) string {
+	if length <= 0 || length > 255*sha256.Size {
+		panic(fmt.Sprintf("invalid derived key length %d", length))
+	}
+
+	// Extract, using the default salt of zeros
+	extractor := hmac.New(sha256.New, make([]byte, sha256.Size))
+	extractor.Write([]byte(secret))
+	pseudoRandomKey := extractor.Sum(nil)
+
+	// Expand
+	key := make([]byte, 0, length+sha256.Size)
+	var block []byte
+	for counter := byte(1); len(key) < length; counter++ {
+		expander := hmac.New(sha256.New, pseudoRandomKey)
+		expander.Write(block)
+		expander.Write([]byte(purpose))
+		expander.Write([]byte{counter})
+		block = expander.Sum(nil)
+		key = append(key, block...)
+	}
+
+	return string(key[:length])
+}
@@ -0,0 +1,78 @@
+package session
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingVerifier(t *testing.T) {
+	oldVerifier := NewEncryptedVerifier(DeriveKey("TheTruthIsOutThere", "session", 32))
+	newVerifier := NewEncryptedVerifier(DeriveKey("TrustNo1", "session", 32))
+
+	old := NewRotatingVerifier(VerifierKey{ID: "1", Verifier: oldVerifier})
+	rotated := NewRotatingVerifier(
+		VerifierKey{ID: "2", Verifier: newVerifier},
+		VerifierKey{ID: "1", Verifier: oldVerifier},
+	)
+
+	message, err := rotated.Encode([]byte("Fox Mulder"))
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(message, "2--"))
+
+	data, err := rotated.Decode(message)
+	require.NoError(t, err)
+	require.Equal(t, "Fox Mulder", string(data))
+
+	// Messages encoded with older keys are still valid
+	message, err = old.Encode([]byte("Dana Scully"))
+	require.NoError(t, err)
+
+	data, err = rotated.Decode(message)
+	require.NoError(t, err)
+	require.Equal(t, "Dana Scully", string(data))
+
+	// Messages encoded before using a RotatingVerifier are still valid
+	message, err = oldVerifier.Encode([]byte("Walter Skinner"))
+	require.NoError(t, err)
+
+	data, err = rotated.Decode(message)
+	require.NoError(t, err)
+	require.Equal(t, "Walter Skinner", string(data))
+
+	// Messages encoded with removed keys are invalid
+	message, err = rotated.Encode([]byte("Cigarette Smoking Man"))
+	require.NoError(t, err)
+
+	_, err = old.Decode(message)
+	require.Error(t, err)
+
+	_, err = rotated.Decode("2--" + strings.TrimPrefix(message, "2--")[1:])
+	require.Error(t, err)
+}
+
+func TestNewRotatingVerifier_InvalidKeys(t *testing.T) {
+	verifier := NewVerifier("TheTruthIsOutThere")
+
+	require.Panics(t, func() { NewRotatingVerifier() })
+	require.Panics(t, func() { NewRotatingVerifier(VerifierKey{ID: "", Verifier: verifier}) })
+	require.Panics(t, func() { NewRotatingVerifier(VerifierKey{ID: "a--b", Verifier: verifier}) })
+	require.Panics(t, func() {
+		NewRotatingVerifier(VerifierKey{ID: "1", Verifier: verifier}, VerifierKey{ID: "1", Verifier: verifier})
+	})
+}
+
+func TestDeriveKey(t *testing.T) {
+	// RFC 5869 test case 3
+	secret, err := hex.DecodeString("0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b")
+	require.NoError(t, err)
+
+	key := DeriveKey(string(secret), "", 42)
+	require.Equal(t, "8da4e775a563c18f715f802a063c5a31b8a11f5c5ee1879ec3454e5f3c738d2d9d201395faa4b61a96c8", hex.EncodeToString([]byte(key)))
+
+	require.Len(t, DeriveKey("TheTruthIsOutThere", "session", 32), 32)
+	require.NotEqual(t, DeriveKey("TheTruthIsOutThere", "session", 32), DeriveKey("TheTruthIsOutThere", "csrf", 32))
+	require.Panics(t, func() { DeriveKey("TheTruthIsOutThere", "session", 255*32+1) })
+}
@@ -7,8 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/blakewilliams/amaro/httprouter"
+	"github.com/blakewilliams/amaro/httprouter/middleware/session/csrf"
 )
 
 // Verifier is an interface that allows a given string of bytes to be encoded
@@ -40,7 +42,7 @@ func Middleware[RC Persistable[T], T any](store Store[T]) func(ctx context.Conte
 			panic(err)
 		}
 
-		data, err := store.FromCookie(cookie)
+		data, loaded, err := store.fromCookie(ctx, cookie)
 		if err != nil {
 			panic(err)
 		}
@@ -52,6 +54,9 @@ func Middleware[RC Persistable[T], T any](store Store[T]) func(ctx context.Conte
 		if err != nil {
 			panic(err)
 		}
+		state := store.state(rc)
+		*state = loaded
+		state.original = originalData
 		rc.SetSessionData(data)
 
 		defer func() {
@@ -60,11 +65,21 @@ func Middleware[RC Persistable[T], T any](store Store[T]) func(ctx context.Conte
 				panic(err)
 			}
 
-			if !bytes.Equal(newData, originalData) {
+			if !bytes.Equal(newData, state.original) {
 				err = store.Write(rc, rc.SessionData())
-				if err != nil {
-					panic(err)
-				}
+			} else if store.backend != nil && state.id != "" {
+				// Only refresh the TTL of unchanged server-side sessions, so
+				// stale data doesn't overwrite concurrent requests and
+				// destroyed sessions aren't recreated.
+				err = store.backend.Touch(ctx, state.id, store.ttl)
+			} else if store.backend == nil && store.cookieOpts.IdleTimeout > 0 && !state.seen.IsZero() && store.now().Sub(state.seen) >= store.seenResolution() {
+				// Cookie sessions with an idle timeout need their last seen
+				// time updated.
+				err = store.Write(rc, rc.SessionData())
+			}
+
+			if err != nil {
+				panic(err)
 			}
 		}()
 
@@ -82,10 +97,48 @@ type Store[T any] struct {
 	Name       string
 	initState  func() T
 	cookieOpts *CookieOptions
+	backend    Backend
+	ttl        time.Duration
+	now        func() time.Time
 }
 
+type (
+	// sessionStates holds the state of the sessions of a request, keyed by
+	// the store name.
+	sessionStates map[string]*sessionState
+
+	sessionState struct {
+		// id is the ID of server-side sessions. It's empty for new sessions
+		// and cookie sessions.
+		id string
+		// original is the marshaled session data when the request started,
+		// or when it was last written. It's nil when the session must be
+		// written, e.g. after Renew.
+		original []byte
+		// created and seen are when the session was created and last
+		// written. They're zero for new sessions.
+		created time.Time
+		seen    time.Time
+	}
+
+	// payload is the signed session data stored in the cookie, or in the
+	// backend for server-side stores. The timestamps are stored in the
+	// payload so they can't be changed by clients.
+	payload struct {
+		Data json.RawMessage `json:"amaro.data"`
+		// CreatedAt and SeenAt are unix timestamps
+		CreatedAt int64 `json:"amaro.createdAt"`
+		SeenAt    int64 `json:"amaro.seenAt"`
+	}
+)
+
 // CookieOptions are the options that are used when creating the underlying
 // http.Cookie.
+//
+// Sessions are stored with the timestamps used by IdleTimeout and
+// AbsoluteTimeout even when they're unset. Versions of this package before
+// timestamps were added can't read these sessions, so rolling back to one
+// logs everyone out.
 type CookieOptions struct {
 	// Path is the path that the cookie is valid for. Defaults to unset.
 	Path string
@@ -101,6 +154,13 @@ type CookieOptions struct {
 	// SameSite indicates whether the cookie should only be sent to the same
 	// site. Defaults to http.SameSiteLaxMode.
 	SameSite http.SameSite
+	// IdleTimeout expires sessions that haven't been used for the given
+	// duration. Unlike MaxAge it's enforced by the server, so copied cookies
+	// stop working. Defaults to unset.
+	IdleTimeout time.Duration
+	// AbsoluteTimeout expires sessions the given duration after they were
+	// created or renewed, regardless of activity. Defaults to unset.
+	AbsoluteTimeout time.Duration
 }
 
 // New creates a new Store with the given name and verifies Data using the
@@ -124,9 +184,29 @@ func New[T any](name string, verifier Verifier, opts *CookieOptions, initState f
 		initState:  initState,
 		verifier:   verifier,
 		cookieOpts: opts,
+		now:        time.Now,
 	}
 }
 
+// NewServerSide creates a new Store that keeps the session data in backend
+// and only stores the session ID, signed using verifier, in the cookie.
+// Sessions expire after ttl without requests, defaulting to 24 hours, and can
+// be revoked using Destroy. The ttl replaces CookieOptions.IdleTimeout, which
+// isn't used by server-side stores.
+//
+// The other arguments are the same as New.
+func NewServerSide[T any](name string, verifier Verifier, backend Backend, ttl time.Duration, opts *CookieOptions, initState func() T) Store[T] {
+	if ttl == 0 {
+		ttl = 24 * time.Hour
+	}
+
+	store := New(name, verifier, opts, initState)
+	store.backend = backend
+	store.ttl = ttl
+
+	return store
+}
+
 // FromRequest reads the cookie with the provided name from the Request. The
 // data is then decoded and verified using the Verifier.
 func (s Store[T]) FromRequest(rc httprouter.RequestContext) (T, error) {
@@ -136,41 +216,258 @@ func (s Store[T]) FromRequest(rc httprouter.RequestContext) (T, error) {
 		return s.initState(), fmt.Errorf("Could not create session from request: %w", err)
 	}
 
-	return s.FromCookie(cookie)
+	data, _, err := s.fromCookie(rc.Request().Context(), cookie)
+	return data, err
 }
 
 // FromCookie attempts to decode the data from the passed in Cookie and verifies
 // the data hasn't been tampered with.
 func (s Store[T]) FromCookie(cookie *http.Cookie) (T, error) {
+	data, _, err := s.fromCookie(context.Background(), cookie)
+	return data, err
+}
+
+// fromCookie decodes the session data of the cookie, loading it from the
+// backend for server-side stores. Expired sessions are returned as new
+// sessions.
+func (s Store[T]) fromCookie(ctx context.Context, cookie *http.Cookie) (T, sessionState, error) {
 	data := s.initState()
 	if cookie == nil {
-		return data, nil
+		return data, sessionState{}, nil
 	}
 
 	decodedMessage, err := s.verifier.Decode(cookie.Value)
 
 	if err != nil {
-		return data, err
+		return data, sessionState{}, err
 	}
 
-	err = json.Unmarshal(decodedMessage, &data)
+	state := sessionState{}
+	if s.backend != nil {
+		state.id = string(decodedMessage)
+		decodedMessage, err = s.backend.Load(ctx, state.id)
+		if errors.Is(err, ErrSessionNotFound) {
+			return data, sessionState{}, nil
+		} else if err != nil {
+			return data, sessionState{}, fmt.Errorf("Could not load session: %w", err)
+		}
+	}
+
+	var p payload
+	if err := json.Unmarshal(decodedMessage, &p); err != nil || p.Data == nil {
+		// Sessions written before timestamps were added only contain the
+		// data. They can't be expired, so they're only used when there are
+		// no timeouts.
+		if s.cookieOpts.IdleTimeout > 0 || s.cookieOpts.AbsoluteTimeout > 0 {
+			return data, sessionState{}, nil
+		}
+
+		p = payload{Data: decodedMessage}
+	} else {
+		state.created = time.Unix(p.CreatedAt, 0)
+		state.seen = time.Unix(p.SeenAt, 0)
+		if s.expired(state) {
+			return data, sessionState{}, nil
+		}
+	}
+
+	err = json.Unmarshal(p.Data, &data)
 
 	if err != nil {
-		return data, fmt.Errorf("Could not decode session: %w", err)
+		return data, sessionState{}, fmt.Errorf("Could not decode session: %w", err)
+	}
+
+	return data, state, nil
+}
+
+// seenResolution returns how often the last seen time of cookie sessions
+// using CookieOptions.IdleTimeout is updated, to avoid writing the session on
+// every request. It's at most half of the idle timeout so sessions in use
+// don't expire.
+func (s Store[T]) seenResolution() time.Duration {
+	return min(time.Minute, s.cookieOpts.IdleTimeout/2)
+}
+
+// expired reports whether the session is past its idle or absolute timeout.
+func (s Store[T]) expired(state sessionState) bool {
+	now := s.now()
+
+	// Server-side sessions use the TTL of the backend as their idle timeout
+	if timeout := s.cookieOpts.IdleTimeout; timeout > 0 && s.backend == nil && now.Sub(state.seen) >= timeout {
+		return true
+	}
+
+	if timeout := s.cookieOpts.AbsoluteTimeout; timeout > 0 && now.Sub(state.created) >= timeout {
+		return true
 	}
 
-	return data, nil
+	return false
 }
 
 // Write encodes the Data into a JSON object, signs it using the message
 // verifier, then writes it to the passed in httprouter.RequestContext using the
 // name provided by New.
+//
+// Server-side stores save the data to the backend instead and write the signed
+// session ID to the cookie.
 func (s Store[T]) Write(rc httprouter.RequestContext, data T) error {
-	cookie, err := s.ToCookie(data)
+	jsonValue, err := s.marshal(data)
+	if err != nil {
+		return err
+	}
+
+	state := s.state(rc)
+	now := s.now()
+	created := state.created
+	if created.IsZero() {
+		created = now
+	}
+
+	sealed, err := s.seal(jsonValue, created, now)
+	if err != nil {
+		return err
+	}
+
+	var cookie *http.Cookie
+	if s.backend == nil {
+		cookie, err = s.encode(sealed)
+		if err != nil {
+			return err
+		}
+	} else {
+		id := state.id
+		if id == "" {
+			id, err = newSessionID()
+			if err != nil {
+				return err
+			}
+		}
+
+		cookie, err = s.save(rc.Request().Context(), id, sealed)
+		if err != nil {
+			return err
+		}
+
+		state.id = id
+	}
+
+	state.original = jsonValue
+	state.created = created
+	state.seen = now
+	http.SetCookie(rc.Response(), cookie)
+
+	return nil
+}
+
+// Renew rotates the session while keeping its data, e.g. after logging in to
+// prevent session fixation. Server-side sessions get a new ID and the old one
+// is deleted, cookie sessions get a new payload. The absolute timeout starts
+// over, and the CSRF token is replaced when rc implements csrf.CSRFable and
+// has one.
+//
+// The session is written when the request finishes.
+func (s Store[T]) Renew(rc Persistable[T]) error {
+	return s.renew(rc, csrfToken(rc))
+}
+
+// Reset replaces the session data with the initial state, keeping the values
+// of the given JSON keys, then renews the session like Renew:
+//
+//	err := store.Reset(rc, "ReturnTo")
+func (s Store[T]) Reset(rc Persistable[T], keep ...string) error {
+	previousToken := csrfToken(rc)
+
+	data := s.initState()
+	if len(keep) > 0 {
+		if err := carryOver(rc.SessionData(), &data, keep); err != nil {
+			return err
+		}
+	}
+	rc.SetSessionData(data)
+
+	return s.renew(rc, previousToken)
+}
+
+func (s Store[T]) renew(rc Persistable[T], previousToken *csrf.Token) error {
+	state := s.state(rc)
+	if s.backend != nil && state.id != "" {
+		if err := s.backend.Destroy(rc.Request().Context(), state.id); err != nil {
+			return err
+		}
+	}
+
+	*state = sessionState{}
+
+	if previousToken != nil {
+		rc.(csrf.CSRFable).SetCSRF(csrf.NewCSRF(csrf.WithTokenLength(previousToken.TokenLength)))
+	}
+
+	return nil
+}
+
+// csrfToken returns the CSRF token of the request, if it has one.
+func csrfToken(rc httprouter.RequestContext) *csrf.Token {
+	if csrfable, ok := rc.(csrf.CSRFable); ok {
+		return csrfable.CSRF()
+	}
+
+	return nil
+}
+
+// carryOver copies the values of the given JSON keys from one session to
+// another.
+func carryOver(from any, to any, keep []string) error {
+	fromJSON, err := json.Marshal(from)
+	if err != nil {
+		return fmt.Errorf("Could not marshal session data: %w", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(fromJSON, &fields); err != nil {
+		return fmt.Errorf("Could not carry over session keys: %w", err)
+	}
+
+	kept := make(map[string]json.RawMessage, len(keep))
+	for _, key := range keep {
+		if value, ok := fields[key]; ok {
+			kept[key] = value
+		}
+	}
+
+	keptJSON, err := json.Marshal(kept)
+	if err != nil {
+		return fmt.Errorf("Could not carry over session keys: %w", err)
+	}
+
+	if err := json.Unmarshal(keptJSON, to); err != nil {
+		return fmt.Errorf("Could not carry over session keys: %w", err)
+	}
+
+	return nil
+}
+
+// Destroy resets the session data of the request and expires the cookie.
+// Server-side sessions are also deleted from the backend, so the session can't
+// be used again even if the cookie was copied.
+func (s Store[T]) Destroy(rc Persistable[T]) error {
+	state := s.state(rc)
+	if s.backend != nil && state.id != "" {
+		if err := s.backend.Destroy(rc.Request().Context(), state.id); err != nil {
+			return err
+		}
+	}
+
+	data := s.initState()
+	original, err := s.marshal(data)
 	if err != nil {
 		return err
 	}
 
+	*state = sessionState{original: original}
+	rc.SetSessionData(data)
+
+	cookie := s.newCookie("")
+	cookie.MaxAge = -1
 	http.SetCookie(rc.Response(), cookie)
 
 	return nil
@@ -184,19 +481,97 @@ func (s Store[T]) marshal(data T) ([]byte, error) {
 	return jsonValue, nil
 }
 
+// state returns the state of the session in the request.
+func (s Store[T]) state(rc httprouter.RequestContext) *sessionState {
+	states, ok := httprouter.Get[sessionStates](rc)
+	if !ok {
+		states = make(sessionStates)
+		httprouter.Set(rc, states)
+	}
+
+	state, ok := states[s.Name]
+	if !ok {
+		state = &sessionState{}
+		states[s.Name] = state
+	}
+
+	return state
+}
+
 // ToCookie returns the underlying http.ToCookie that is used to store the session.
+//
+// Server-side stores save the data to the backend as a new session and return
+// a cookie containing its ID.
 func (s Store[T]) ToCookie(data T) (*http.Cookie, error) {
 	jsonValue, err := s.marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	sealed, err := s.seal(jsonValue, now, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.backend != nil {
+		id, err := newSessionID()
+		if err != nil {
+			return nil, err
+		}
+
+		return s.save(context.Background(), id, sealed)
+	}
+
+	return s.encode(sealed)
+}
+
+// seal wraps the marshaled session data in a payload with its timestamps.
+func (s Store[T]) seal(jsonValue []byte, created time.Time, seen time.Time) ([]byte, error) {
+	sealed, err := json.Marshal(payload{
+		Data:      jsonValue,
+		CreatedAt: created.Unix(),
+		SeenAt:    seen.Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Could not marshal session data: %w", err)
+	}
+
+	return sealed, nil
+}
 
-	encodedData, err := s.verifier.Encode(jsonValue)
+// encode returns the cookie of a cookie session.
+func (s Store[T]) encode(sealed []byte) (*http.Cookie, error) {
+	encodedData, err := s.verifier.Encode(sealed)
 	if err != nil {
 		return nil, fmt.Errorf("could not encode data: %w", err)
 	}
 
+	return s.newCookie(encodedData), nil
+}
+
+// save saves server-side session data and returns the cookie containing the
+// signed session ID.
+func (s Store[T]) save(ctx context.Context, id string, jsonValue []byte) (*http.Cookie, error) {
+	if err := s.backend.Save(ctx, id, jsonValue, s.ttl); err != nil {
+		return nil, fmt.Errorf("could not save session: %w", err)
+	}
+
+	encodedID, err := s.verifier.Encode([]byte(id))
+	if err != nil {
+		return nil, fmt.Errorf("could not encode session ID: %w", err)
+	}
+
+	return s.newCookie(encodedID), nil
+}
+
+// newCookie returns the session cookie with the given value using the cookie
+// options of the store.
+func (s Store[T]) newCookie(value string) *http.Cookie {
 	cookie := &http.Cookie{
 		Name:  s.Name,
 		Path:  "/",
-		Value: string(encodedData),
+		Value: value,
 	}
 
 	if s.cookieOpts.Domain != "" {
@@ -216,5 +591,5 @@ func (s Store[T]) ToCookie(data T) (*http.Cookie, error) {
 	cookie.Secure = s.cookieOpts.Secure
 	cookie.HttpOnly = s.cookieOpts.HTTPOnly
 
-	return cookie, nil
+	return cookie
 }
@@ -5,8 +5,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/blakewilliams/amaro/httprouter"
+	"github.com/blakewilliams/amaro/httprouter/middleware/session/csrf"
 	"github.com/stretchr/testify/require"
 )
 
@@ -121,3 +123,349 @@ func TestCookieOptions(t *testing.T) {
 	require.True(t, cookie.Secure)
 	require.True(t, cookie.HttpOnly)
 }
+
+func TestServerSide(t *testing.T) {
+	verifier := NewVerifier("TheTruthIsOutThere")
+	backend := NewMemoryBackend()
+	router := httprouter.New(func(rc httprouter.RequestContext) *requestContext {
+		return &requestContext{
+			RequestContext: rc,
+		}
+	})
+
+	store := NewServerSide[*MyData]("session", verifier, backend, time.Hour, nil, func() *MyData { return &MyData{} })
+	router.Use(Middleware[*requestContext, *MyData](store))
+
+	router.Get("/login", func(ctx context.Context, rc *requestContext) {
+		rc.session.UserID = 500
+		rc.session.Name = "Fox Mulder"
+	})
+
+	router.Get("/", func(ctx context.Context, rc *requestContext) {
+		require.Equal(t, 500, rc.session.UserID)
+		require.Equal(t, "Fox Mulder", rc.session.Name)
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/login", nil))
+
+	cookie := res.Result().Cookies()[0]
+	require.NotContains(t, cookie.Value, "Fox Mulder")
+
+	id, err := verifier.Decode(cookie.Value)
+	require.NoError(t, err)
+	saved, err := backend.Load(context.Background(), string(id))
+	require.NoError(t, err)
+	require.Contains(t, string(saved), `{"UserID":500,"Name":"Fox Mulder"}`)
+
+	// Unchanged sessions have their TTL refreshed without being saved
+	backend.now = func() time.Time { return time.Now().Add(30 * time.Minute) }
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	require.Empty(t, res.Result().Cookies())
+
+	backend.now = func() time.Time { return time.Now().Add(80 * time.Minute) }
+	_, err = backend.Load(context.Background(), string(id))
+	require.NoError(t, err)
+
+	data, err := store.FromCookie(cookie)
+	require.NoError(t, err)
+	require.Equal(t, 500, data.UserID)
+}
+
+func TestServerSide_Destroy(t *testing.T) {
+	verifier := NewVerifier("TheTruthIsOutThere")
+	backend := NewMemoryBackend()
+	router := httprouter.New(func(rc httprouter.RequestContext) *requestContext {
+		return &requestContext{
+			RequestContext: rc,
+		}
+	})
+
+	store := NewServerSide[*MyData]("session", verifier, backend, 0, nil, func() *MyData { return &MyData{} })
+	router.Use(Middleware[*requestContext, *MyData](store))
+
+	router.Get("/logout", func(ctx context.Context, rc *requestContext) {
+		require.Equal(t, 500, rc.session.UserID)
+
+		err := store.Destroy(rc)
+		require.NoError(t, err)
+		require.Equal(t, 0, rc.session.UserID)
+	})
+
+	cookie, err := store.ToCookie(&MyData{UserID: 500, Name: "Fox Mulder"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/logout", nil)
+	req.AddCookie(cookie)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	cookies := res.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, -1, cookies[0].MaxAge)
+
+	// The old cookie no longer loads the session
+	data, err := store.FromCookie(cookie)
+	require.NoError(t, err)
+	require.Equal(t, 0, data.UserID)
+}
+
+type renewData struct {
+	UserID   int
+	ReturnTo string
+	CSRF     *csrf.Token
+}
+
+type renewRequestContext struct {
+	session *renewData
+	httprouter.RequestContext
+}
+
+func (rc *renewRequestContext) SetSessionData(session *renewData) {
+	rc.session = session
+}
+
+func (rc *renewRequestContext) SessionData() *renewData {
+	return rc.session
+}
+
+func (rc *renewRequestContext) CSRF() *csrf.Token {
+	return rc.session.CSRF
+}
+
+func (rc *renewRequestContext) SetCSRF(token *csrf.Token) {
+	rc.session.CSRF = token
+}
+
+func TestRenew(t *testing.T) {
+	verifier := NewVerifier("TheTruthIsOutThere")
+	backend := NewMemoryBackend()
+	router := httprouter.New(func(rc httprouter.RequestContext) *renewRequestContext {
+		return &renewRequestContext{
+			RequestContext: rc,
+		}
+	})
+
+	store := NewServerSide[*renewData]("session", verifier, backend, 0, nil, func() *renewData { return &renewData{} })
+	router.Use(Middleware[*renewRequestContext, *renewData](store))
+
+	var previousToken []byte
+	router.Post("/login", func(ctx context.Context, rc *renewRequestContext) {
+		previousToken = rc.session.CSRF.Value
+
+		err := store.Renew(rc)
+		require.NoError(t, err)
+		rc.session.UserID = 500
+	})
+
+	oldToken := csrf.NewCSRF()
+	cookie, err := store.ToCookie(&renewData{ReturnTo: "/files", CSRF: oldToken})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.AddCookie(cookie)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	newCookie := res.Result().Cookies()[0]
+	require.NotEqual(t, cookie.Value, newCookie.Value)
+
+	data, err := store.FromCookie(newCookie)
+	require.NoError(t, err)
+	require.Equal(t, 500, data.UserID)
+	require.Equal(t, "/files", data.ReturnTo)
+	require.NotEqual(t, previousToken, data.CSRF.Value)
+	require.Equal(t, oldToken.TokenLength, data.CSRF.TokenLength)
+
+	// The previous session ID can no longer be used
+	data, err = store.FromCookie(cookie)
+	require.NoError(t, err)
+	require.Equal(t, 0, data.UserID)
+	require.Equal(t, "", data.ReturnTo)
+}
+
+func TestReset(t *testing.T) {
+	verifier := NewVerifier("TheTruthIsOutThere")
+	router := httprouter.New(func(rc httprouter.RequestContext) *renewRequestContext {
+		return &renewRequestContext{
+			RequestContext: rc,
+		}
+	})
+
+	store := New[*renewData]("session", verifier, nil, func() *renewData { return &renewData{} })
+	router.Use(Middleware[*renewRequestContext, *renewData](store))
+
+	router.Post("/login", func(ctx context.Context, rc *renewRequestContext) {
+		err := store.Reset(rc, "ReturnTo")
+		require.NoError(t, err)
+
+		require.Equal(t, 0, rc.session.UserID)
+		require.Equal(t, "/files", rc.session.ReturnTo)
+		require.NotNil(t, rc.session.CSRF)
+	})
+
+	oldToken := csrf.NewCSRF()
+	cookie, err := store.ToCookie(&renewData{UserID: 10, ReturnTo: "/files", CSRF: oldToken})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.AddCookie(cookie)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	data, err := store.FromCookie(res.Result().Cookies()[0])
+	require.NoError(t, err)
+	require.Equal(t, 0, data.UserID)
+	require.Equal(t, "/files", data.ReturnTo)
+	require.NotEqual(t, oldToken.Value, data.CSRF.Value)
+}
+
+func TestTimeouts(t *testing.T) {
+	verifier := NewVerifier("TheTruthIsOutThere")
+	options := &CookieOptions{
+		IdleTimeout:     time.Hour,
+		AbsoluteTimeout: 24 * time.Hour,
+	}
+	store := New[*MyData]("session", verifier, options, func() *MyData { return &MyData{} })
+
+	created := time.Now()
+	now := created
+	store.now = func() time.Time { return now }
+	cookie, err := store.ToCookie(&MyData{UserID: 500})
+	require.NoError(t, err)
+
+	now = created.Add(59 * time.Minute)
+	data, err := store.FromCookie(cookie)
+	require.NoError(t, err)
+	require.Equal(t, 500, data.UserID)
+
+	now = created.Add(61 * time.Minute)
+	data, err = store.FromCookie(cookie)
+	require.NoError(t, err)
+	require.Equal(t, 0, data.UserID)
+
+	// Requests refresh the idle timeout, but not the absolute timeout
+	router := httprouter.New(func(rc httprouter.RequestContext) *requestContext {
+		return &requestContext{
+			RequestContext: rc,
+		}
+	})
+	router.Use(Middleware[*requestContext, *MyData](store))
+	router.Get("/", func(ctx context.Context, rc *requestContext) {})
+
+	for elapsed := 30 * time.Minute; elapsed < 24*time.Hour; elapsed += 30 * time.Minute {
+		now = created.Add(elapsed)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(cookie)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+
+		require.Len(t, res.Result().Cookies(), 1)
+		cookie = res.Result().Cookies()[0]
+	}
+
+	data, err = store.FromCookie(cookie)
+	require.NoError(t, err)
+	require.Equal(t, 500, data.UserID)
+
+	now = created.Add(24 * time.Hour)
+	data, err = store.FromCookie(cookie)
+	require.NoError(t, err)
+	require.Equal(t, 0, data.UserID)
+}
+
+func TestTimeouts_LegacyPayload(t *testing.T) {
+	verifier := NewVerifier("TheTruthIsOutThere")
+	legacy, err := verifier.Encode([]byte(`{"UserID":500}`))
+	require.NoError(t, err)
+	cookie := &http.Cookie{Name: "session", Value: legacy}
+
+	store := New[*MyData]("session", verifier, nil, func() *MyData { return &MyData{} })
+	data, err := store.FromCookie(cookie)
+	require.NoError(t, err)
+	require.Equal(t, 500, data.UserID)
+
+	store = New[*MyData]("session", verifier, &CookieOptions{IdleTimeout: time.Hour}, func() *MyData { return &MyData{} })
+	data, err = store.FromCookie(cookie)
+	require.NoError(t, err)
+	require.Equal(t, 0, data.UserID)
+}
+
+func TestServerSide_DestroyDuringRequest(t *testing.T) {
+	verifier := NewVerifier("TheTruthIsOutThere")
+	backend := NewMemoryBackend()
+	router := httprouter.New(func(rc httprouter.RequestContext) *requestContext {
+		return &requestContext{
+			RequestContext: rc,
+		}
+	})
+
+	store := NewServerSide[*MyData]("session", verifier, backend, 0, nil, func() *MyData { return &MyData{} })
+	router.Use(Middleware[*requestContext, *MyData](store))
+
+	cookie, err := store.ToCookie(&MyData{UserID: 500, Name: "Fox Mulder"})
+	require.NoError(t, err)
+	id, err := verifier.Decode(cookie.Value)
+	require.NoError(t, err)
+
+	// Simulates a logout in a concurrent request while this request is
+	// handled
+	router.Get("/", func(ctx context.Context, rc *requestContext) {
+		require.Equal(t, 500, rc.session.UserID)
+
+		err := backend.Destroy(ctx, string(id))
+		require.NoError(t, err)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	_, err = backend.Load(context.Background(), string(id))
+	require.ErrorIs(t, err, ErrSessionNotFound)
+
+	data, err := store.FromCookie(cookie)
+	require.NoError(t, err)
+	require.Equal(t, 0, data.UserID)
+}
+
+func TestTimeouts_ShortIdleTimeout(t *testing.T) {
+	verifier := NewVerifier("TheTruthIsOutThere")
+	store := New[*MyData]("session", verifier, &CookieOptions{IdleTimeout: time.Minute}, func() *MyData { return &MyData{} })
+
+	created := time.Now()
+	now := created
+	store.now = func() time.Time { return now }
+	cookie, err := store.ToCookie(&MyData{UserID: 500})
+	require.NoError(t, err)
+
+	router := httprouter.New(func(rc httprouter.RequestContext) *requestContext {
+		return &requestContext{
+			RequestContext: rc,
+		}
+	})
+	router.Use(Middleware[*requestContext, *MyData](store))
+	router.Get("/", func(ctx context.Context, rc *requestContext) {
+		require.Equal(t, 500, rc.session.UserID)
+	})
+
+	// Requests every 40 seconds keep the session alive
+	for elapsed := 40 * time.Second; elapsed < 10*time.Minute; elapsed += 40 * time.Second {
+		now = created.Add(elapsed)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(cookie)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+
+		if cookies := res.Result().Cookies(); len(cookies) > 0 {
+			cookie = cookies[0]
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/blakewilliams/amaro/httprouter"
+)
+
+// TimeoutConfig configures the Timeout middleware.
+type TimeoutConfig struct {
+	// Duration is how long the handler has to write a response before the
+	// timeout response is written instead.
+	Duration time.Duration
+	// Status is the status written when the deadline is exceeded. Defaults to
+	// http.StatusServiceUnavailable, http.StatusGatewayTimeout is also common.
+	Status int
+	// Body is the body written when the deadline is exceeded. Defaults to the
+	// status text of Status.
+	Body string
+	// ContentType is the Content-Type of Body. Defaults to
+	// `text/plain; charset=utf-8`.
+	ContentType string
+}
+
+// Timeout sets a deadline on the context passed to the rest of the middleware
+// stack and the handler. Handlers should pass the context to slow operations,
+// like database queries, so they're cancelled when the deadline is exceeded.
+//
+// The handler runs in its own goroutine and writes to a separate buffer. If it
+// finishes in time the buffered response is copied to the original Response,
+// otherwise the original Response is cleared using `Response.Clear` and the
+// timeout response is written. Anything written by a handler that finishes
+// late is discarded and http.ErrHandlerTimeout is returned from its writes, so
+// the response is never flushed twice. Handlers should stop using the Response
+// once the context is done, since after the deadline Header returns the header
+// of the original Response so outer middleware can still modify it.
+//
+// Panics raised by the handler before the deadline are re-raised so that
+// ErrorHandler can recover them. Panics raised after the deadline are logged
+// using RequestLogger since a response has already been written.
+//
+// When the request context is cancelled before the deadline, e.g. because the
+// client disconnected, the handler's response is used as is rather than being
+// treated as a timeout.
+func Timeout[T httprouter.RequestContext](config TimeoutConfig) httprouter.Middleware[T] {
+	if config.Status == 0 {
+		config.Status = http.StatusServiceUnavailable
+	}
+
+	if config.Body == "" {
+		config.Body = http.StatusText(config.Status)
+	}
+
+	if config.ContentType == "" {
+		config.ContentType = "text/plain; charset=utf-8"
+	}
+
+	return func(ctx context.Context, rctx T, next httprouter.Handler[T]) {
+		expires := time.Now().Add(config.Duration)
+		ctx, cancel := context.WithDeadlineCause(ctx, expires, errTimeout)
+		defer cancel()
+
+		logger := RequestLogger(rctx)
+
+		original := rctx.Response()
+		tw := &timeoutResponse{
+			original: original,
+			status:   original.Status(),
+			header:   original.Header().Clone(),
+		}
+
+		// Sync the context before the handler starts so the goroutine doesn't
+		// have to replace the request while outer middleware read it.
+		rctx.WithContext(ctx)
+		rctx.WithResponse(tw)
+
+		done := make(chan struct{})
+		panicked := make(chan any, 1)
+
+		go func() {
+			defer func() {
+				if rec := recover(); rec != nil {
+					// Hand the panic off while holding the lock so it's
+					// either re-raised or logged, never dropped.
+					tw.mu.Lock()
+					timedOut := tw.timedOut
+					if !timedOut {
+						panicked <- rec
+					}
+					tw.mu.Unlock()
+
+					if timedOut {
+						logger.Error("panic after request timeout", "error", recoveredError(rec), "stack", string(debug.Stack()))
+					}
+				}
+			}()
+
+			next(ctx, rctx)
+			close(done)
+		}()
+
+		// Requests cancelled for another reason, like the client
+		// disconnecting, wait for the handler to return since it's expected
+		// to stop once the context is cancelled, or for the deadline.
+		deadline := ctx.Done()
+		var expired <-chan time.Time
+
+		for {
+			select {
+			case <-done:
+				rctx.WithResponse(original)
+				tw.commit()
+				return
+			case rec := <-panicked:
+				rctx.WithResponse(original)
+				panic(rec)
+			case <-deadline:
+				if !errors.Is(context.Cause(ctx), errTimeout) {
+					deadline = nil
+					expired = time.After(time.Until(expires))
+					continue
+				}
+			case <-expired:
+			}
+
+			// The handler is still running and may use rctx, so the original
+			// Response is not restored. The router won't reuse the
+			// RequestContext as a result.
+			tw.timeout(config)
+
+			select {
+			case rec := <-panicked:
+				logger.Error("panic after request timeout", "error", recoveredError(rec))
+			default:
+			}
+
+			return
+		}
+	}
+}
+
+// errTimeout is the cause of contexts cancelled by Timeout.
+var errTimeout = errors.New("request timeout")
+
+// timeoutResponse buffers the response written by a handler running under
+// Timeout so it can be discarded if the deadline is exceeded.
+type timeoutResponse struct {
+	mu       sync.Mutex
+	original httprouter.Response
+	header   http.Header
+	status   int
+	body     []byte
+	timedOut bool
+}
+
+var _ httprouter.Response = (*timeoutResponse)(nil)
+
+// Header returns the buffered header map, which is copied to the original
+// Response when the handler finishes in time. Once the deadline is exceeded the
+// header of the original Response is returned instead so headers set by outer
+// middleware are written, while the buffered map stays with the handler.
+func (t *timeoutResponse) Header() http.Header {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.timedOut {
+		return t.original.Header()
+	}
+
+	return t.header
+}
+
+// WriteHeader buffers the status code of the response.
+func (t *timeoutResponse) WriteHeader(status int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.timedOut {
+		t.status = status
+	}
+}
+
+// Write buffers b, returning http.ErrHandlerTimeout if the deadline has
+// been exceeded.
+func (t *timeoutResponse) Write(b []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	t.body = append(t.body, b...)
+	return len(b), nil
+}
+
+// Status returns the status that will be written to the client.
+func (t *timeoutResponse) Status() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.timedOut {
+		return t.original.Status()
+	}
+
+	return t.status
+}
+
+// Clear resets the buffered body.
+func (t *timeoutResponse) Clear() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.body = t.body[:0]
+}
+
+// Body returns the buffered body, or the timeout response body once the
+// deadline is exceeded.
+func (t *timeoutResponse) Body() []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.timedOut {
+		return t.original.Body()
+	}
+
+	return t.body
+}
+
+// Flush copies the buffered response to the original Response and flushes
+// it. Once the deadline is exceeded only the timeout response is flushed.
+func (t *timeoutResponse) Flush() (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.timedOut {
+		t.commitLocked()
+	}
+
+	return t.original.Flush()
+}
+
+// commit copies the buffered response to the original Response.
+func (t *timeoutResponse) commit() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.commitLocked()
+}
+
+func (t *timeoutResponse) commitLocked() {
+	header := t.original.Header()
+	clear(header)
+	for key, values := range t.header {
+		header[key] = values
+	}
+
+	t.original.WriteHeader(t.status)
+	_, _ = t.original.Write(t.body)
+	t.body = t.body[:0]
+}
+
+// timeout discards the buffered response and writes the timeout response to
+// the original Response.
+func (t *timeoutResponse) timeout(config TimeoutConfig) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.timedOut = true
+	t.body = nil
+
+	t.original.Clear()
+	t.original.Header().Set("Content-Type", config.ContentType)
+	t.original.WriteHeader(config.Status)
+	_, _ = t.original.Write([]byte(config.Body))
+}
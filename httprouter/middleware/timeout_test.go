@@ -0,0 +1,252 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blakewilliams/amaro/httprouter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeout(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+
+	router.Use(Timeout[httprouter.RequestContext](TimeoutConfig{Duration: 50 * time.Millisecond}))
+
+	var hasDeadline, requestHasDeadline bool
+	router.Get("/fast", func(ctx context.Context, r httprouter.RequestContext) {
+		_, hasDeadline = ctx.Deadline()
+		_, requestHasDeadline = r.Request().Context().Deadline()
+
+		r.Response().Header().Set("X-Handler", "fast")
+		r.Response().WriteHeader(http.StatusCreated)
+		_, _ = r.Response().Write([]byte("all good!"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/fast", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	require.True(t, hasDeadline)
+	require.True(t, requestHasDeadline)
+	require.Equal(t, http.StatusCreated, res.Code)
+	require.Equal(t, "fast", res.Header().Get("X-Handler"))
+	require.Equal(t, "all good!", res.Body.String())
+}
+
+func TestTimeout_DeadlineExceeded(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+
+	var status int
+	router.Use(func(ctx context.Context, r httprouter.RequestContext, next httprouter.Handler[httprouter.RequestContext]) {
+		next(ctx, r)
+		status = r.Response().Status()
+	})
+
+	router.Use(Timeout[httprouter.RequestContext](TimeoutConfig{
+		Duration: 10 * time.Millisecond,
+		Status:   http.StatusGatewayTimeout,
+		Body:     "too slow",
+	}))
+
+	finished := make(chan error)
+	router.Get("/slow", func(ctx context.Context, r httprouter.RequestContext) {
+		r.Response().Header().Set("X-Handler", "slow")
+		_, _ = r.Response().Write([]byte("partial"))
+
+		<-ctx.Done()
+
+		// Give the middleware time to write the timeout response.
+		time.Sleep(10 * time.Millisecond)
+
+		r.Response().WriteHeader(http.StatusOK)
+		_, err := r.Response().Write([]byte("late"))
+		_, _ = r.Response().Flush()
+		finished <- err
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/slow", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusGatewayTimeout, res.Code)
+	require.Equal(t, http.StatusGatewayTimeout, status)
+	require.Equal(t, "too slow", res.Body.String())
+	require.Equal(t, "text/plain; charset=utf-8", res.Header().Get("Content-Type"))
+	require.Empty(t, res.Header().Get("X-Handler"))
+
+	require.ErrorIs(t, <-finished, http.ErrHandlerTimeout)
+	require.Equal(t, "too slow", res.Body.String())
+}
+
+func TestTimeout_Defaults(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+
+	router.Get(
+		"/slow",
+		func(ctx context.Context, r httprouter.RequestContext) {
+			<-ctx.Done()
+		},
+		Timeout[httprouter.RequestContext](TimeoutConfig{Duration: time.Millisecond}),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/slow", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusServiceUnavailable, res.Code)
+	require.Equal(t, "Service Unavailable", res.Body.String())
+}
+
+func TestTimeout_Panic(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	router.Use(ErrorHandler(logger, func(ctx context.Context, r httprouter.RequestContext, err any) {
+		r.Response().WriteHeader(http.StatusInternalServerError)
+		_, _ = r.Response().Write([]byte("something went wrong"))
+	}))
+
+	router.Use(Timeout[httprouter.RequestContext](TimeoutConfig{Duration: time.Second}))
+
+	router.Get("/panic", func(ctx context.Context, r httprouter.RequestContext) {
+		panic(errors.New("omg"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusInternalServerError, res.Code)
+	require.Equal(t, "something went wrong", res.Body.String())
+}
+
+func TestTimeout_ClientDisconnect(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+
+	router.Use(Timeout[httprouter.RequestContext](TimeoutConfig{Duration: time.Second}))
+
+	router.Get("/", func(ctx context.Context, r httprouter.RequestContext) {
+		<-ctx.Done()
+		r.Response().WriteHeader(http.StatusNoContent)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	res := httptest.NewRecorder()
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+	start := time.Now()
+	router.ServeHTTP(res, req)
+
+	require.Less(t, time.Since(start), time.Second)
+	require.Equal(t, http.StatusNoContent, res.Code)
+}
+
+func TestTimeout_PanicAfterDeadline(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+
+	logs := &syncBuffer{}
+	router.Use(Logger[httprouter.RequestContext](slog.New(slog.NewTextHandler(logs, nil))))
+	router.Use(Timeout[httprouter.RequestContext](TimeoutConfig{Duration: 10 * time.Millisecond}))
+
+	panicked := make(chan struct{})
+	router.Get("/", func(ctx context.Context, r httprouter.RequestContext) {
+		<-ctx.Done()
+		defer close(panicked)
+		panic(errors.New("late panic"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	require.Equal(t, http.StatusServiceUnavailable, res.Code)
+
+	<-panicked
+	require.Eventually(t, func() bool {
+		return strings.Contains(logs.String(), "panic after request timeout")
+	}, time.Second, time.Millisecond)
+	require.Contains(t, logs.String(), "late panic")
+}
+
+func TestTimeout_HeadersAfterDeadline(t *testing.T) {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+
+	timedOut := make(chan struct{})
+	router.Use(func(ctx context.Context, r httprouter.RequestContext, next httprouter.Handler[httprouter.RequestContext]) {
+		next(ctx, r)
+		close(timedOut)
+
+		// Runs while the handler is still writing to its own header map
+		for i := 0; i < 100; i++ {
+			r.Response().Header().Set("X-Outer", "set")
+		}
+	})
+	router.Use(Timeout[httprouter.RequestContext](TimeoutConfig{Duration: 10 * time.Millisecond}))
+
+	finished := make(chan struct{})
+	router.Get("/", func(ctx context.Context, r httprouter.RequestContext) {
+		defer close(finished)
+
+		header := r.Response().Header()
+		<-ctx.Done()
+		<-timedOut
+
+		for i := 0; i < 100; i++ {
+			header.Set("X-Handler", "late")
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	<-finished
+
+	require.Equal(t, http.StatusServiceUnavailable, res.Code)
+	require.Equal(t, "set", res.Header().Get("X-Outer"))
+	require.Empty(t, res.Header().Get("X-Handler"))
+}
+
+// syncBuffer is a bytes.Buffer that can be written to by multiple goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/blakewilliams/amaro/httprouter"
+	"github.com/blakewilliams/amaro/trace"
+)
+
+// Tracing starts a span for each request named after the method and matched
+// route, e.g. `GET /users/:id`. The trace is continued from the W3C
+// traceparent header when present, and the span is added to the context so
+// handlers can start child spans and pass the trace to outgoing requests and
+// jobs using trace.Inject and job.JobManager.Enqueue.
+//
+// Responses with a 5xx status mark the span as failed. When Tracing is used
+// before ErrorHandler, the recovered panic is recorded on the span.
+func Tracing[T httprouter.RequestContext](tracer *trace.Tracer) httprouter.Middleware[T] {
+	return func(ctx context.Context, rctx T, next httprouter.Handler[T]) {
+		req := rctx.Request()
+		route := rctx.MatchedPath()
+
+		name := req.Method
+		if route != "" {
+			name += " " + route
+		}
+
+		ctx, span := tracer.Start(trace.Extract(ctx, req.Header), name)
+		defer span.End()
+
+		span.SetAttribute("http.request.method", req.Method)
+		span.SetAttribute("http.route", route)
+		span.SetAttribute("url.path", req.URL.Path)
+		if id := GetRequestID(rctx); id != "" {
+			span.SetAttribute("http.request.id", id)
+		}
+
+		defer func() {
+			if rec := recover(); rec != nil {
+				span.RecordError(recoveredError(rec))
+				span.SetAttribute("http.response.status_code", http.StatusInternalServerError)
+				panic(rec)
+			}
+		}()
+
+		next(ctx, rctx)
+
+		status := rctx.Response().Status()
+		span.SetAttribute("http.response.status_code", status)
+		if status >= 500 {
+			span.SetStatus(trace.StatusError, http.StatusText(status))
+		}
+	}
+}
+
+// recoveredError converts a recovered panic value to an error.
+func recoveredError(rec any) error {
+	if err, ok := rec.(error); ok {
+		return err
+	}
+
+	return fmt.Errorf("panic: %v", rec)
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blakewilliams/amaro/httprouter"
+	"github.com/blakewilliams/amaro/trace"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracing(t *testing.T) {
+	exporter := trace.NewInMemoryExporter()
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+	router.Use(RequestID[httprouter.RequestContext]())
+	router.Use(Tracing[httprouter.RequestContext](trace.NewTracer(exporter)))
+
+	var handlerSpan *trace.Span
+	router.Get("/users/:id", func(ctx context.Context, r httprouter.RequestContext) {
+		handlerSpan = trace.SpanFromContext(ctx)
+		trace.Inject(ctx, r.Response().Header())
+	})
+	router.Get("/fail", func(ctx context.Context, r httprouter.RequestContext) {
+		r.Response().WriteHeader(http.StatusBadGateway)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set(trace.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	spans := exporter.Spans()
+	require.Len(t, spans, 1)
+
+	span := spans[0]
+	require.Equal(t, handlerSpan, span)
+	require.Equal(t, "GET /users/:id", span.Name)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID.String())
+	require.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID.String())
+	require.Equal(t, span.SpanContext.Traceparent(), res.Header().Get(trace.TraceparentHeader))
+	require.Equal(t, "/users/:id", span.Attributes["http.route"])
+	require.Equal(t, "/users/1", span.Attributes["url.path"])
+	require.Equal(t, res.Header().Get(RequestIDHeader), span.Attributes["http.request.id"])
+	require.Equal(t, http.StatusOK, span.Attributes["http.response.status_code"])
+	require.Equal(t, trace.StatusUnset, span.Status)
+
+	exporter.Reset()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	spans = exporter.Spans()
+	require.Len(t, spans, 1)
+	require.False(t, spans[0].Parent.IsValid())
+	require.Equal(t, trace.StatusError, spans[0].Status)
+	require.Equal(t, http.StatusBadGateway, spans[0].Attributes["http.response.status_code"])
+
+	exporter.Reset()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	spans = exporter.Spans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "GET", spans[0].Name)
+}
+
+func TestTracing_ErrorHandler(t *testing.T) {
+	exporter := trace.NewInMemoryExporter()
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	router.Use(Tracing[httprouter.RequestContext](trace.NewTracer(exporter)))
+	router.Use(ErrorHandler(logger, func(ctx context.Context, r httprouter.RequestContext, err any) {
+		r.Response().WriteHeader(http.StatusInternalServerError)
+	}))
+
+	router.Get("/panic", func(ctx context.Context, r httprouter.RequestContext) {
+		panic("omg")
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	require.Equal(t, http.StatusInternalServerError, res.Code)
+
+	spans := exporter.Spans()
+	require.Len(t, spans, 1)
+	require.Equal(t, trace.StatusError, spans[0].Status)
+	require.Equal(t, "panic: omg", spans[0].StatusMessage)
+	require.Len(t, spans[0].Errors, 1)
+}
+
+func TestTracing_Panic(t *testing.T) {
+	exporter := trace.NewInMemoryExporter()
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+	router.Use(Tracing[httprouter.RequestContext](trace.NewTracer(exporter)))
+	router.Get("/panic", func(ctx context.Context, r httprouter.RequestContext) {
+		panic("omg")
+	})
+
+	require.Panics(t, func() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	})
+
+	spans := exporter.Spans()
+	require.Len(t, spans, 1)
+	require.Equal(t, trace.StatusError, spans[0].Status)
+	require.Equal(t, http.StatusInternalServerError, spans[0].Attributes["http.response.status_code"])
+}
@@ -0,0 +1,333 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/blakewilliams/amaro/httprouter"
+)
+
+type (
+	// UploadStore stores uploaded files while they're handled. Files are
+	// deleted after the request unless they're moved by the handler.
+	UploadStore interface {
+		// Store saves the contents of r and returns a key used to open or
+		// delete the file. The error returned by r must be returned so
+		// uploads that are too large fail.
+		Store(ctx context.Context, filename string, r io.Reader) (string, error)
+		// Open opens the file with the given key.
+		Open(ctx context.Context, key string) (io.ReadCloser, error)
+		// Delete deletes the file with the given key. Deleting a file that
+		// doesn't exist is not an error.
+		Delete(ctx context.Context, key string) error
+	}
+
+	// UploadConfig configures the Uploads middleware.
+	UploadConfig struct {
+		// Store stores uploaded files. Defaults to DirUploadStore using the
+		// default temporary directory.
+		Store UploadStore
+		// MaxFileSize is the maximum size of each file in bytes. Defaults to
+		// 10MB.
+		MaxFileSize int64
+		// MaxFiles is the maximum number of files per request. Defaults to 10.
+		MaxFiles int
+		// MaxValuesSize is the maximum combined size of non-file form values
+		// in bytes. Defaults to 1MB.
+		MaxValuesSize int64
+		// AllowedTypes are the content types files can have, detected from
+		// their contents using http.DetectContentType. Wildcards like
+		// `image/*` are supported. All types are allowed when empty.
+		AllowedTypes []string
+	}
+
+	// Upload holds the files and form values of a multipart request.
+	Upload struct {
+		Files  []*UploadedFile
+		Values url.Values
+	}
+
+	// UploadedFile is a file stored by an UploadStore.
+	UploadedFile struct {
+		// Field is the name of the form field of the file.
+		Field string
+		// Filename is the name of the file sent by the client. It must not
+		// be trusted as a path.
+		Filename string
+		// ContentType is detected from the contents of the file.
+		ContentType string
+		// Size is the size of the file in bytes.
+		Size int64
+		// Key identifies the file in the UploadStore. For DirUploadStore it's
+		// the path of the file.
+		Key string
+
+		store UploadStore
+	}
+
+	// uploads tracks the config and stored files of a request.
+	uploads struct {
+		config UploadConfig
+		mu     sync.Mutex
+		files  []*UploadedFile
+	}
+
+	dirUploadStore struct {
+		dir string
+	}
+)
+
+// Uploads enables ParseUpload for the request and deletes all stored files
+// once the request has been handled. Request bodies should also be limited
+// using BodyLimit.
+func Uploads[T httprouter.RequestContext](config UploadConfig) httprouter.Middleware[T] {
+	if config.Store == nil {
+		config.Store = DirUploadStore("")
+	}
+
+	if config.MaxFileSize == 0 {
+		config.MaxFileSize = 10 << 20
+	}
+
+	if config.MaxFiles == 0 {
+		config.MaxFiles = 10
+	}
+
+	if config.MaxValuesSize == 0 {
+		config.MaxValuesSize = 1 << 20
+	}
+
+	return func(ctx context.Context, rctx T, next httprouter.Handler[T]) {
+		u := &uploads{config: config}
+		httprouter.Set(rctx, u)
+
+		defer func() {
+			u.mu.Lock()
+			defer u.mu.Unlock()
+
+			for _, file := range u.files {
+				if err := file.store.Delete(context.WithoutCancel(ctx), file.Key); err != nil {
+					RequestLogger(rctx).Error("failed to delete upload", "key", file.Key, "error", err)
+				}
+			}
+		}()
+
+		next(ctx, rctx)
+	}
+}
+
+// ParseUpload streams the files of a multipart request to the UploadStore
+// configured by the Uploads middleware, validating their size and type.
+// Errors are returned as an *httprouter.HTTPError with the status to render:
+// 400 for malformed requests, 413 for files that are too large, and 415 for
+// files that aren't allowed.
+//
+// Files are deleted after the request, handlers must copy or move files they
+// want to keep.
+func ParseUpload(ctx context.Context, rctx httprouter.RequestContext) (*Upload, error) {
+	u, ok := httprouter.Get[*uploads](rctx)
+	if !ok {
+		return nil, errors.New("ParseUpload requires the Uploads middleware")
+	}
+
+	reader, err := rctx.Request().MultipartReader()
+	if err != nil {
+		return nil, httprouter.NewHTTPError(http.StatusBadRequest, "Expected a multipart form", err)
+	}
+
+	upload := &Upload{Values: make(url.Values)}
+	valuesSize := int64(0)
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, uploadError(err)
+		}
+
+		if part.FileName() == "" {
+			value, err := io.ReadAll(io.LimitReader(part, u.config.MaxValuesSize-valuesSize+1))
+			if err != nil {
+				return nil, uploadError(err)
+			}
+
+			valuesSize += int64(len(value))
+			if valuesSize > u.config.MaxValuesSize {
+				return nil, httprouter.NewHTTPError(http.StatusRequestEntityTooLarge, "Form values are too large", nil)
+			}
+
+			upload.Values.Add(part.FormName(), string(value))
+			continue
+		}
+
+		if len(upload.Files) >= u.config.MaxFiles {
+			return nil, httprouter.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("At most %d files can be uploaded", u.config.MaxFiles), nil)
+		}
+
+		file, err := u.store(ctx, part.FormName(), part.FileName(), part)
+		if err != nil {
+			return nil, err
+		}
+
+		upload.Files = append(upload.Files, file)
+	}
+
+	return upload, nil
+}
+
+// store validates and stores a single file.
+func (u *uploads) store(ctx context.Context, field string, filename string, r io.Reader) (*UploadedFile, error) {
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(r, sniff)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, uploadError(err)
+	}
+	sniff = sniff[:n]
+
+	contentType := http.DetectContentType(sniff)
+	if !typeAllowed(u.config.AllowedTypes, contentType) {
+		return nil, httprouter.NewHTTPError(http.StatusUnsupportedMediaType, fmt.Sprintf("%s files are not allowed", filename), nil)
+	}
+
+	counter := &uploadCounter{r: io.MultiReader(bytes.NewReader(sniff), r), limit: u.config.MaxFileSize}
+	key, err := u.config.Store.Store(ctx, filename, counter)
+	if key != "" && err != nil {
+		_ = u.config.Store.Delete(context.WithoutCancel(ctx), key)
+	}
+	if err != nil {
+		if counter.exceeded {
+			return nil, httprouter.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("%s is too large", filename), err)
+		}
+
+		return nil, uploadError(err)
+	}
+
+	file := &UploadedFile{
+		Field:       field,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        counter.n,
+		Key:         key,
+		store:       u.config.Store,
+	}
+
+	u.mu.Lock()
+	u.files = append(u.files, file)
+	u.mu.Unlock()
+
+	return file, nil
+}
+
+// Open opens the stored file.
+func (f *UploadedFile) Open(ctx context.Context) (io.ReadCloser, error) {
+	return f.store.Open(ctx, f.Key)
+}
+
+// uploadError converts errors reading the request to an HTTPError.
+func uploadError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return httprouter.AsHTTPError(err)
+	}
+
+	return httprouter.NewHTTPError(http.StatusBadRequest, "Invalid multipart form", err)
+}
+
+func typeAllowed(allowed []string, contentType string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	for _, pattern := range allowed {
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == pattern {
+			return true
+		}
+	}
+
+	return false
+}
+
+// uploadCounter counts the bytes read and fails once more than limit bytes
+// are read.
+type uploadCounter struct {
+	r        io.Reader
+	limit    int64
+	n        int64
+	exceeded bool
+}
+
+var errUploadTooLarge = errors.New("upload is too large")
+
+func (c *uploadCounter) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	c.n += int64(n)
+	if c.n > c.limit {
+		c.exceeded = true
+		return n, errUploadTooLarge
+	}
+
+	return n, err
+}
+
+var safeExtension = regexp.MustCompile(`^\.[a-zA-Z0-9]{1,10}$`)
+
+// DirUploadStore returns an UploadStore that writes files to dir, or the
+// default temporary directory when dir is empty.
+func DirUploadStore(dir string) UploadStore {
+	return &dirUploadStore{dir: dir}
+}
+
+// Store implements UploadStore.
+func (s *dirUploadStore) Store(ctx context.Context, filename string, r io.Reader) (string, error) {
+	// Keep the extension so the file type is recognizable, but only when
+	// it's safe to use in a path.
+	ext := filepath.Ext(filepath.Base(filename))
+	if !safeExtension.MatchString(ext) {
+		ext = ""
+	}
+
+	f, err := os.CreateTemp(s.dir, "upload-*"+ext)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return f.Name(), err
+	}
+
+	return f.Name(), f.Close()
+}
+
+// Open implements UploadStore.
+func (s *dirUploadStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return os.Open(key)
+}
+
+// Delete implements UploadStore.
+func (s *dirUploadStore) Delete(ctx context.Context, key string) error {
+	err := os.Remove(key)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blakewilliams/amaro/httprouter"
+	"github.com/stretchr/testify/require"
+)
+
+var pngHeader = []byte("\x89PNG\x0D\x0A\x1A\x0A")
+
+func newUploadRequest(t *testing.T, files map[string][]byte, values map[string]string) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range values {
+		require.NoError(t, writer.WriteField(name, value))
+	}
+	for name, contents := range files {
+		part, err := writer.CreateFormFile("file", name)
+		require.NoError(t, err)
+		_, _ = part.Write(contents)
+	}
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return req
+}
+
+func newUploadRouter(dir string, handler httprouter.FallibleHandler[httprouter.RequestContext]) *httprouter.Router[httprouter.RequestContext] {
+	router := httprouter.New(func(r httprouter.RequestContext) httprouter.RequestContext {
+		return r
+	})
+	router.Use(Uploads[httprouter.RequestContext](UploadConfig{
+		Store:        DirUploadStore(dir),
+		MaxFileSize:  32,
+		MaxFiles:     2,
+		AllowedTypes: []string{"image/*", "text/plain"},
+	}))
+	router.Post("/", httprouter.Fallible(handler))
+
+	return router
+}
+
+func TestUploads(t *testing.T) {
+	dir := t.TempDir()
+
+	var upload *Upload
+	var contents []byte
+	router := newUploadRouter(dir, func(ctx context.Context, r httprouter.RequestContext) error {
+		var err error
+		upload, err = ParseUpload(ctx, r)
+		if err != nil {
+			return err
+		}
+
+		f, err := upload.Files[0].Open(ctx)
+		require.NoError(t, err)
+		defer f.Close()
+
+		contents, err = io.ReadAll(f)
+		return err
+	})
+
+	png := append(append([]byte{}, pngHeader...), "image"...)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, newUploadRequest(t, map[string][]byte{"../avatar.png": png}, map[string]string{"name": "amaro"}))
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+
+	require.Equal(t, "amaro", upload.Values.Get("name"))
+	require.Len(t, upload.Files, 1)
+
+	file := upload.Files[0]
+	require.Equal(t, "file", file.Field)
+	require.Equal(t, "avatar.png", file.Filename)
+	require.Equal(t, "image/png", file.ContentType)
+	require.Equal(t, int64(len(png)), file.Size)
+	require.Equal(t, dir, filepath.Dir(file.Key))
+	require.Equal(t, ".png", filepath.Ext(file.Key))
+	require.Equal(t, png, contents)
+
+	// Files are deleted after the request
+	_, err := os.Stat(file.Key)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestUploads_Validation(t *testing.T) {
+	dir := t.TempDir()
+	router := newUploadRouter(dir, func(ctx context.Context, r httprouter.RequestContext) error {
+		_, err := ParseUpload(ctx, r)
+		return err
+	})
+
+	tests := map[string]struct {
+		files  map[string][]byte
+		status int
+	}{
+		"too large": {
+			files:  map[string][]byte{"large.txt": bytes.Repeat([]byte("a"), 33)},
+			status: http.StatusRequestEntityTooLarge,
+		},
+		"type not allowed": {
+			files:  map[string][]byte{"page.html": []byte("<!DOCTYPE html><html></html>")},
+			status: http.StatusUnsupportedMediaType,
+		},
+		"too many files": {
+			files:  map[string][]byte{"a.txt": []byte("a"), "b.txt": []byte("b"), "c.txt": []byte("c")},
+			status: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, newUploadRequest(t, tc.files, nil))
+			require.Equal(t, tc.status, res.Code)
+		})
+	}
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/", nil))
+	require.Equal(t, http.StatusBadRequest, res.Code)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
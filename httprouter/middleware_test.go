@@ -0,0 +1,197 @@
+package httprouter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func trackingMiddleware(name string) Middleware[*TrackingRequestContext] {
+	return func(ctx context.Context, r *TrackingRequestContext, next Handler[*TrackingRequestContext]) {
+		r.AddToChain(name)
+		next(ctx, r)
+	}
+}
+
+func TestRouter_RouteMiddleware(t *testing.T) {
+	var tracking *TrackingRequestContext
+	router := New(func(r RequestContext) *TrackingRequestContext {
+		tracking = &TrackingRequestContext{RequestContext: r}
+		return tracking
+	})
+	router.Use(trackingMiddleware("router"))
+
+	group := router.Group("/api")
+	group.Use(trackingMiddleware("group"))
+	group.Get("/limited", func(ctx context.Context, r *TrackingRequestContext) {
+		r.AddToChain("handler")
+	}, trackingMiddleware("route 1"), trackingMiddleware("route 2"))
+	group.Get("/open", func(ctx context.Context, r *TrackingRequestContext) {
+		r.AddToChain("handler")
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/limited", nil))
+	require.Equal(t, []string{"router", "group", "route 1", "route 2", "handler"}, tracking.Chain)
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/open", nil))
+	require.Equal(t, []string{"router", "group", "handler"}, tracking.Chain)
+}
+
+func TestController_RouteMiddleware(t *testing.T) {
+	var tracking *TrackingRequestContext
+	router := New(func(r RequestContext) *TrackingRequestContext {
+		tracking = &TrackingRequestContext{RequestContext: r}
+		return tracking
+	})
+
+	controller := NewController(router, &TrackingData{})
+	controller.Use(trackingMiddleware("controller 1"))
+	controller.Use(trackingMiddleware("controller 2"))
+	controller.Group("/sub").Get("/best", func(ctx context.Context, r *TrackingRequestContext, p *TrackingData) {
+		r.AddToChain("handler")
+	}, trackingMiddleware("route"))
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/sub/best", nil))
+
+	require.Equal(t, []string{"controller 1", "controller 2", "route", "FromRequest", "handler"}, tracking.Chain)
+}
+
+func TestChain(t *testing.T) {
+	var tracking *TrackingRequestContext
+	router := New(func(r RequestContext) *TrackingRequestContext {
+		tracking = &TrackingRequestContext{RequestContext: r}
+		return tracking
+	})
+
+	auth := Chain(trackingMiddleware("session"), trackingMiddleware("auth"))
+	router.Get("/", func(ctx context.Context, r *TrackingRequestContext) {
+		r.AddToChain("handler")
+	}, auth, trackingMiddleware("after"))
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Equal(t, []string{"session", "auth", "after", "handler"}, tracking.Chain)
+}
+
+func TestSkipIf(t *testing.T) {
+	var tracking *TrackingRequestContext
+	router := New(func(r RequestContext) *TrackingRequestContext {
+		tracking = &TrackingRequestContext{RequestContext: r}
+		return tracking
+	})
+
+	isHealthCheck := func(ctx context.Context, r *TrackingRequestContext) bool {
+		return strings.HasPrefix(r.Request().URL.Path, "/_health")
+	}
+	router.Use(SkipIf(isHealthCheck, trackingMiddleware("logger")))
+
+	handler := func(ctx context.Context, r *TrackingRequestContext) { r.AddToChain("handler") }
+	router.Get("/_health", handler)
+	router.Get("/", handler)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/_health", nil))
+	require.Equal(t, []string{"handler"}, tracking.Chain)
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, []string{"logger", "handler"}, tracking.Chain)
+}
+
+func routerMiddleware(ctx context.Context, r *rootRequestContext, next Handler[*rootRequestContext]) {
+	next(ctx, r)
+}
+
+func groupMiddleware(ctx context.Context, r *rootRequestContext, next Handler[*rootRequestContext]) {
+	next(ctx, r)
+}
+
+func routeMiddleware(ctx context.Context, r *rootRequestContext, next Handler[*rootRequestContext]) {
+	next(ctx, r)
+}
+
+func TestRouter_Routes(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Use(routerMiddleware)
+	router.Get("/", func(ctx context.Context, r *rootRequestContext) {})
+
+	group := router.Group("/admin")
+	group.Use(groupMiddleware)
+	group.Delete("/users/:id", func(ctx context.Context, r *rootRequestContext) {}, routeMiddleware)
+
+	routes := router.Routes()
+	require.Len(t, routes, 2)
+
+	require.Equal(t, http.MethodGet, routes[0].Method)
+	require.Equal(t, "/", routes[0].Path)
+	require.Equal(t, []string{"github.com/blakewilliams/amaro/httprouter.routerMiddleware"}, routes[0].MiddlewareNames())
+
+	require.Equal(t, http.MethodDelete, routes[1].Method)
+	require.Equal(t, "/admin/users/:id", routes[1].Path)
+	require.Equal(
+		t,
+		[]string{
+			"github.com/blakewilliams/amaro/httprouter.routerMiddleware",
+			"github.com/blakewilliams/amaro/httprouter.groupMiddleware",
+			"github.com/blakewilliams/amaro/httprouter.routeMiddleware",
+		},
+		routes[1].MiddlewareNames(),
+	)
+}
+
+type traceKey struct{}
+
+func TestRouter_ContextPropagation(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Use(func(ctx context.Context, r *rootRequestContext, next Handler[*rootRequestContext]) {
+		next(context.WithValue(ctx, traceKey{}, "router"), r)
+	})
+
+	group := router.Group("/api")
+	group.Use(func(ctx context.Context, r *rootRequestContext, next Handler[*rootRequestContext]) {
+		require.Equal(t, "router", r.Request().Context().Value(traceKey{}))
+
+		ctx = context.WithValue(ctx, contextKey{}, "group")
+		r.WithContext(ctx)
+		next(ctx, r)
+	})
+
+	group.Get("/", func(ctx context.Context, r *rootRequestContext) {
+		require.Equal(t, ctx, r.Request().Context())
+		require.Equal(t, "router", r.Request().Context().Value(traceKey{}))
+		require.Equal(t, "group", r.Request().Context().Value(contextKey{}))
+
+		r.Response().WriteHeader(http.StatusNoContent)
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api", nil))
+	require.Equal(t, http.StatusNoContent, res.Code)
+}
+
+func TestRouter_WithRequest(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Use(func(ctx context.Context, r *rootRequestContext, next Handler[*rootRequestContext]) {
+		req := r.Request().Clone(ctx)
+		req.Header.Set("X-Tenant", "fbi")
+		r.WithRequest(req)
+
+		next(ctx, r)
+	})
+
+	router.Get("/", func(ctx context.Context, r *rootRequestContext) {
+		_, _ = r.Response().Write([]byte(r.Request().Header.Get("X-Tenant")))
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, "fbi", res.Body.String())
+}
@@ -0,0 +1,101 @@
+package httprouter
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// RouteNamer is implemented by Registerable types that can assign names to
+// routes. Names are forwarded to the Router with the path prefixes applied so
+// that paths can later be generated using Router.Path.
+type RouteNamer interface {
+	// NameRoute assigns a name to the route registered with the given method
+	// and path.
+	NameRoute(method string, path string, name string)
+}
+
+var _ RouteNamer = (*Router[*rootRequestContext])(nil)
+var _ RouteNamer = (*Group[*rootRequestContext])(nil)
+var _ RouteNamer = (*Controller[*rootRequestContext, *placeholderFromRequest])(nil)
+
+// NameRoute assigns a name to the route registered with the given method and
+// path. Multiple routes can share a name as long as they share a path, e.g. the
+// GET and PATCH routes for a resource.
+func (r *Router[T]) NameRoute(method string, path string, name string) {
+	path = cleanRoutePath(path)
+
+	if existing, ok := r.names[name]; ok && existing != path {
+		panic(fmt.Sprintf("route name %q is already used by %s", name, existing))
+	}
+
+	for i := len(r.routes) - 1; i >= 0; i-- {
+		route := r.routes[i]
+		if route.Method == method && route.Path == path {
+			route.Name = name
+			r.names[name] = path
+			return
+		}
+	}
+
+	panic(fmt.Sprintf("can not name route %q, no route is registered for %s %s", name, method, path))
+}
+
+// Path returns the path of the named route with its params replaced by the
+// given values, e.g. `/posts/:id` becomes `/posts/5`. An error is returned if
+// the route doesn't exist or a param is missing.
+func (r *Router[T]) Path(name string, params map[string]string) (string, error) {
+	path, ok := r.names[name]
+	if !ok {
+		return "", fmt.Errorf("no route named %q", name)
+	}
+
+	parts := normalizeRoutePath(path)
+	for i, part := range parts {
+		if !strings.HasPrefix(part, ":") && !strings.HasPrefix(part, "*") {
+			continue
+		}
+
+		value, ok := params[part[1:]]
+		if !ok {
+			return "", fmt.Errorf("missing param %q for route %q", part[1:], name)
+		}
+
+		if strings.HasPrefix(part, "*") {
+			parts[i] = value
+		} else {
+			parts[i] = url.PathEscape(value)
+		}
+	}
+
+	return "/" + strings.Join(parts, "/"), nil
+}
+
+// NameRoute implements the RouteNamer interface and forwards the name to the
+// parent with the group prefix applied.
+func (g *Group[T]) NameRoute(method string, path string, name string) {
+	nameRoute(g.parent, method, joinURL(g.prefix, path), name)
+}
+
+// NameRoute implements the RouteNamer interface and forwards the name to the
+// parent.
+func (r *Controller[T, RequestData]) NameRoute(method string, path string, name string) {
+	nameRoute(r.parent, method, path, name)
+}
+
+// NameRoute implements the RouteNamer interface and forwards the name to the
+// parent with the group prefix applied.
+func (r *controllerGroup[T, RequestData]) NameRoute(method string, path string, name string) {
+	nameRoute(r.parent, method, joinURL(r.prefix, path), name)
+}
+
+// nameRoute forwards the route name to parent, panicking if the parent can't
+// name routes.
+func nameRoute[T RequestContext](parent Registerable[T], method string, path string, name string) {
+	namer, ok := parent.(RouteNamer)
+	if !ok {
+		panic(fmt.Sprintf("can not name route %q, %T does not implement RouteNamer", name, parent))
+	}
+
+	namer.NameRoute(method, path, name)
+}
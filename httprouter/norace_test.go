@@ -0,0 +1,7 @@
+//go:build !race
+
+package httprouter
+
+// raceEnabled is true when tests are run with the race detector, which adds
+// allocations.
+const raceEnabled = false
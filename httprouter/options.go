@@ -0,0 +1,114 @@
+package httprouter
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+type (
+	// PathPolicy determines how the router handles request paths that differ
+	// from a registered route only by their formatting, like a trailing slash
+	// or duplicate slashes.
+	PathPolicy int
+
+	// Option configures optional router behavior and is passed to New.
+	Option func(*options)
+
+	options struct {
+		trailingSlash   PathPolicy
+		cleanPath       PathPolicy
+		caseInsensitive bool
+		noAutoOptions   bool
+	}
+)
+
+const (
+	// PathStrict only matches paths exactly as they were registered. This is
+	// the default behavior.
+	PathStrict PathPolicy = iota
+	// PathRedirect redirects the client to the canonical path. GET and HEAD
+	// requests receive a 301, all other methods receive a 308 so the method
+	// and body are preserved.
+	PathRedirect
+	// PathMatch silently routes the request as if the canonical path was
+	// requested.
+	PathMatch
+)
+
+// WithTrailingSlash sets the policy used when a request path does not match a
+// route but would match if a trailing slash was added or removed.
+func WithTrailingSlash(policy PathPolicy) Option {
+	return func(o *options) {
+		o.trailingSlash = policy
+	}
+}
+
+// WithCleanPath sets the policy used when a request path contains duplicate
+// slashes or `.` and `..` segments.
+func WithCleanPath(policy PathPolicy) Option {
+	return func(o *options) {
+		o.cleanPath = policy
+	}
+}
+
+// WithCaseInsensitive makes static route segments match regardless of case.
+// Route params retain the case used in the request.
+func WithCaseInsensitive() Option {
+	return func(o *options) {
+		o.caseInsensitive = true
+	}
+}
+
+// WithoutAutoOptions disables the automatic responses to OPTIONS requests
+// for paths without an OPTIONS route. See Router.Match.
+func WithoutAutoOptions() Option {
+	return func(o *options) {
+		o.noAutoOptions = true
+	}
+}
+
+// cleanPath returns the canonical form of p, removing duplicate slashes and
+// resolving `.` and `..` segments while preserving a trailing slash.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+
+	cleaned := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+
+	return cleaned
+}
+
+// toggleTrailingSlash adds a trailing slash to p if it's missing, otherwise
+// the trailing slash is removed.
+func toggleTrailingSlash(p string) string {
+	if strings.HasSuffix(p, "/") {
+		return strings.TrimSuffix(p, "/")
+	}
+
+	return p + "/"
+}
+
+// redirectToPath redirects the client to the given path, preserving the query
+// string of the original request.
+func redirectToPath(rw http.ResponseWriter, req *http.Request, p string) {
+	status := http.StatusPermanentRedirect
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		status = http.StatusMovedPermanently
+	}
+
+	// Collapse leading slashes so paths like `//evil.com/` aren't treated as
+	// protocol-relative URLs redirecting to another host. Backslashes, which
+	// browsers also treat as slashes, are escaped in request paths.
+	p = "/" + strings.TrimLeft(p, "/")
+
+	if req.URL.RawQuery != "" {
+		p += "?" + req.URL.RawQuery
+	}
+
+	http.Redirect(rw, req, p, status)
+}
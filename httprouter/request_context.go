@@ -1,52 +1,92 @@
 package httprouter
 
 import (
+	"context"
 	"net/http"
+	"sync/atomic"
 )
 
 // RequestContext is an interface that exposes the http.Request,
 // http.ResponseWriter, and route params to a handler. Custom types can
 // implement this interface to be passed to handlers of the router.
+//
+// The router reuses RequestContext storage between requests, so it and the
+// params map must not be retained after the handler returns.
 type RequestContext interface {
-	// Request returns the original *http.Request
+	// Request returns the *http.Request. Its context is kept in sync with the
+	// context passed down the middleware stack.
 	Request() *http.Request
+	// WithRequest replaces the *http.Request for the remainder of the
+	// middleware stack and the handler.
+	WithRequest(*http.Request)
+	// WithContext replaces the context of the *http.Request. Middleware
+	// should pass the same context to the next handler.
+	WithContext(context.Context)
 	// Writer returns a router.Response
 	Response() Response
+	// WithResponse replaces the Response for the remainder of the middleware
+	// stack and the handler. The router won't reuse the RequestContext unless
+	// the original Response is restored before the middleware returns.
+	WithResponse(Response)
 	// Params returns the parameters extracted from the URL path based on the
 	// matched route.
 	Params() map[string]string
 	// MatchedPath returns the path that was matched by the router.
 	MatchedPath() string
+	// Values returns the typed per-request value store. See Set and Get.
+	Values() *Values
 }
 
 // BasicRequestContext is a basic implementation of RequestContext. It can be embedded in
 // other types to provide a default implementation of the RequestContext interface.
 type rootRequestContext struct {
-	req         *http.Request
+	// req is accessed atomically since middleware like Timeout run the
+	// handler in a separate goroutine.
+	req         atomic.Pointer[http.Request]
 	res         Response
 	params      map[string]string
 	matchedPath string
+	values      Values
+
+	// writer and paramValues are storage reused by the router between
+	// requests to avoid allocating.
+	writer      responseWriter
+	paramValues []string
 }
 
 var _ RequestContext = (*rootRequestContext)(nil)
 
 func NewRequestContext(req *http.Request, res http.ResponseWriter, matchedPath string, routeParams map[string]string) *rootRequestContext {
-	return &rootRequestContext{
-		req:         req,
+	reqCtx := &rootRequestContext{
 		res:         newResponseWriter(res),
 		matchedPath: matchedPath,
 		params:      routeParams,
 	}
+	reqCtx.req.Store(req)
+
+	return reqCtx
 }
 
 func (r *rootRequestContext) Request() *http.Request {
-	return r.req
+	return r.req.Load()
+}
+
+func (r *rootRequestContext) WithRequest(req *http.Request) {
+	r.req.Store(req)
+}
+
+func (r *rootRequestContext) WithContext(ctx context.Context) {
+	r.req.Store(r.req.Load().WithContext(ctx))
 }
 
 func (r *rootRequestContext) Response() Response {
 	return r.res
 }
 
+func (r *rootRequestContext) WithResponse(res Response) {
+	r.res = res
+}
+
 func (r *rootRequestContext) Params() map[string]string {
 	return r.params
 }
@@ -54,3 +94,43 @@ func (r *rootRequestContext) Params() map[string]string {
 func (r *rootRequestContext) MatchedPath() string {
 	return r.matchedPath
 }
+
+func (r *rootRequestContext) Values() *Values {
+	return &r.values
+}
+
+// newPooledRequestContext returns an empty RequestContext that is reused by
+// the router via reset and release.
+func newPooledRequestContext() *rootRequestContext {
+	return &rootRequestContext{
+		params:      make(map[string]string),
+		paramValues: make([]string, 0, 8),
+	}
+}
+
+// reset prepares a pooled RequestContext to serve the given request.
+func (r *rootRequestContext) reset(req *http.Request, rw http.ResponseWriter, matchedPath string) {
+	r.req.Store(req)
+	r.matchedPath = matchedPath
+	r.writer.reset(rw)
+	r.res = &r.writer
+}
+
+// reusable reports whether the RequestContext can be returned to the pool. It
+// can't be reused when middleware replaced the Response, since the handler may
+// still be holding it, e.g. after a Timeout.
+func (r *rootRequestContext) reusable() bool {
+	return r.res == Response(&r.writer)
+}
+
+// release clears references to the finished request so the RequestContext can
+// be returned to the pool.
+func (r *rootRequestContext) release() {
+	r.req.Store(nil)
+	r.res = nil
+	r.matchedPath = ""
+	r.writer.reset(nil)
+	r.paramValues = r.paramValues[:0]
+	clear(r.params)
+	r.values.reset()
+}
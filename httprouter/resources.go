@@ -0,0 +1,217 @@
+package httprouter
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+type (
+	// ResourceAction is one of the conventional actions registered by
+	// Resources.
+	ResourceAction string
+
+	// ResourceHandlers holds the handlers for the conventional actions of a
+	// resource. Nil handlers are not registered.
+	ResourceHandlers[T RequestContext, RequestData FromRequest[T]] struct {
+		// Index handles `GET /posts`
+		Index ControllerHandler[T, RequestData]
+		// New handles `GET /posts/new`
+		New ControllerHandler[T, RequestData]
+		// Create handles `POST /posts`
+		Create ControllerHandler[T, RequestData]
+		// Show handles `GET /posts/:id`
+		Show ControllerHandler[T, RequestData]
+		// Edit handles `GET /posts/:id/edit`
+		Edit ControllerHandler[T, RequestData]
+		// Update handles `PATCH /posts/:id` and `PUT /posts/:id`
+		Update ControllerHandler[T, RequestData]
+		// Destroy handles `DELETE /posts/:id`
+		Destroy ControllerHandler[T, RequestData]
+	}
+
+	// ResourceOption configures the routes registered by Resources.
+	ResourceOption func(*resourceOptions)
+
+	resourceOptions struct {
+		only   []ResourceAction
+		except []ResourceAction
+		name   string
+		param  string
+	}
+
+	// Resource represents the routes registered by Resources. It implements
+	// Registerable so nested resources can be registered below the member
+	// path, e.g. `/posts/:post_id/comments`.
+	Resource[T RequestContext, RequestData FromRequest[T]] struct {
+		parent     *controllerGroup[T, RequestData]
+		memberPath string
+		name       string
+	}
+)
+
+const (
+	ActionIndex   ResourceAction = "index"
+	ActionNew     ResourceAction = "new"
+	ActionCreate  ResourceAction = "create"
+	ActionShow    ResourceAction = "show"
+	ActionEdit    ResourceAction = "edit"
+	ActionUpdate  ResourceAction = "update"
+	ActionDestroy ResourceAction = "destroy"
+)
+
+var _ Registerable[*rootRequestContext] = (*Resource[*rootRequestContext, *placeholderFromRequest])(nil)
+var _ RouteNamer = (*Resource[*rootRequestContext, *placeholderFromRequest])(nil)
+
+// Only limits the actions registered by Resources to the given actions.
+func Only(actions ...ResourceAction) ResourceOption {
+	return func(o *resourceOptions) {
+		o.only = actions
+	}
+}
+
+// Except prevents the given actions from being registered by Resources.
+func Except(actions ...ResourceAction) ResourceOption {
+	return func(o *resourceOptions) {
+		o.except = actions
+	}
+}
+
+// ResourceName sets the singular name of the resource used for route names.
+// Defaults to the last segment of the resource path with the trailing `s`
+// removed, e.g. `/posts` becomes `post`.
+func ResourceName(name string) ResourceOption {
+	return func(o *resourceOptions) {
+		o.name = name
+	}
+}
+
+// ResourceParam sets the name of the param that identifies the resource in
+// nested resource paths. Defaults to the singular name followed by `_id`, e.g.
+// `/posts/:post_id/comments`.
+func ResourceParam(param string) ResourceOption {
+	return func(o *resourceOptions) {
+		o.param = param
+	}
+}
+
+// Resources registers the conventional routes for a resource at the given path
+// and names them after the resource:
+//
+//	GET    /posts          Index    posts
+//	GET    /posts/new      New      new_post
+//	POST   /posts          Create   posts
+//	GET    /posts/:id      Show     post
+//	GET    /posts/:id/edit Edit     edit_post
+//	PATCH  /posts/:id      Update   post
+//	PUT    /posts/:id      Update   post
+//	DELETE /posts/:id      Destroy  post
+//
+// Forms can reach the PATCH, PUT, and DELETE routes using metal.MethodRewrite.
+func (r *controllerGroup[T, RequestData]) Resources(resourcePath string, handlers ResourceHandlers[T, RequestData], opts ...ResourceOption) *Resource[T, RequestData] {
+	plural := path.Base(cleanRoutePath("/" + resourcePath))
+	options := resourceOptions{name: singularize(plural)}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.param == "" {
+		options.param = options.name + "_id"
+	}
+
+	memberPath := joinURL(resourcePath, "/:id")
+	routes := []struct {
+		action  ResourceAction
+		method  string
+		path    string
+		name    string
+		handler ControllerHandler[T, RequestData]
+	}{
+		{ActionIndex, http.MethodGet, resourcePath, plural, handlers.Index},
+		{ActionNew, http.MethodGet, joinURL(resourcePath, "/new"), "new_" + options.name, handlers.New},
+		{ActionCreate, http.MethodPost, resourcePath, plural, handlers.Create},
+		{ActionShow, http.MethodGet, memberPath, options.name, handlers.Show},
+		{ActionEdit, http.MethodGet, joinURL(memberPath, "/edit"), "edit_" + options.name, handlers.Edit},
+		{ActionUpdate, http.MethodPatch, memberPath, options.name, handlers.Update},
+		{ActionUpdate, http.MethodPut, memberPath, options.name, handlers.Update},
+		{ActionDestroy, http.MethodDelete, memberPath, options.name, handlers.Destroy},
+	}
+
+	for _, route := range routes {
+		if route.handler == nil || !options.includes(route.action) {
+			continue
+		}
+
+		r.Match(route.method, route.path, route.handler)
+		r.NameRoute(route.method, route.path, route.name)
+	}
+
+	return &Resource[T, RequestData]{
+		parent:     r,
+		memberPath: joinURL(resourcePath, "/:"+options.param),
+		name:       options.name,
+	}
+}
+
+// Resources registers the conventional routes for a resource at the given
+// path. See controllerGroup.Resources for the routes that are registered.
+func (r *Controller[T, RequestData]) Resources(resourcePath string, handlers ResourceHandlers[T, RequestData], opts ...ResourceOption) *Resource[T, RequestData] {
+	return r.root.Resources(resourcePath, handlers, opts...)
+}
+
+// RawMatch implements the Registerable interface and registers the route below
+// the member path of the resource.
+func (r *Resource[T, RequestData]) RawMatch(method string, path string, fn Handler[T], middleware ...Middleware[T]) {
+	r.parent.RawMatch(method, joinURL(r.memberPath, path), fn, middleware...)
+}
+
+// rawMatchStack implements lazyRegisterable and registers the route below the
+// member path of the resource.
+func (r *Resource[T, RequestData]) rawMatchStack(method string, path string, fn Handler[T], stack func() []Middleware[T]) {
+	r.parent.rawMatchStack(method, joinURL(r.memberPath, path), fn, stack)
+}
+
+// NameRoute implements the RouteNamer interface and prefixes the name with the
+// resource name. The `new_` and `edit_` prefixes of nested resources are kept at
+// the front so names read like `new_post_comment`.
+func (r *Resource[T, RequestData]) NameRoute(method string, path string, name string) {
+	for _, prefix := range []string{"new_", "edit_"} {
+		if strings.HasPrefix(name, prefix) {
+			r.parent.NameRoute(method, joinURL(r.memberPath, path), prefix+r.name+"_"+strings.TrimPrefix(name, prefix))
+			return
+		}
+	}
+
+	r.parent.NameRoute(method, joinURL(r.memberPath, path), r.name+"_"+name)
+}
+
+func (o resourceOptions) includes(action ResourceAction) bool {
+	if len(o.only) > 0 && !containsAction(o.only, action) {
+		return false
+	}
+
+	return !containsAction(o.except, action)
+}
+
+func containsAction(actions []ResourceAction, action ResourceAction) bool {
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// singularize naively converts a plural resource name to its singular form.
+// ResourceName can be used when this produces the wrong name.
+func singularize(plural string) string {
+	switch {
+	case strings.HasSuffix(plural, "ies"):
+		return strings.TrimSuffix(plural, "ies") + "y"
+	case strings.HasSuffix(plural, "s") && !strings.HasSuffix(plural, "ss"):
+		return strings.TrimSuffix(plural, "s")
+	default:
+		return plural
+	}
+}
@@ -0,0 +1,175 @@
+package httprouter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func resourceHandler(action string) ControllerHandler[*rootRequestContext, *PostData] {
+	return func(ctx context.Context, r *rootRequestContext, p *PostData) {
+		_, _ = r.Response().Write([]byte(fmt.Sprintf("%s %v", action, r.Params())))
+	}
+}
+
+func postHandlers() ResourceHandlers[*rootRequestContext, *PostData] {
+	return ResourceHandlers[*rootRequestContext, *PostData]{
+		Index:   resourceHandler("index"),
+		New:     resourceHandler("new"),
+		Create:  resourceHandler("create"),
+		Show:    resourceHandler("show"),
+		Edit:    resourceHandler("edit"),
+		Update:  resourceHandler("update"),
+		Destroy: resourceHandler("destroy"),
+	}
+}
+
+func TestController_Resources(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	controller := NewController(router, &PostData{})
+	controller.Resources("/posts", postHandlers())
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, "/posts", "index map[]"},
+		{http.MethodGet, "/posts/new", "new map[]"},
+		{http.MethodPost, "/posts", "create map[]"},
+		{http.MethodGet, "/posts/5", "show map[id:5]"},
+		{http.MethodGet, "/posts/5/edit", "edit map[id:5]"},
+		{http.MethodPatch, "/posts/5", "update map[id:5]"},
+		{http.MethodPut, "/posts/5", "update map[id:5]"},
+		{http.MethodDelete, "/posts/5", "destroy map[id:5]"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, httptest.NewRequest(tc.method, tc.path, nil))
+
+			require.Equal(t, http.StatusOK, res.Code)
+			require.Equal(t, tc.body, res.Body.String())
+		})
+	}
+
+	names := map[string]string{}
+	for _, route := range router.Routes() {
+		names[route.Method+" "+route.Path] = route.Name
+	}
+
+	require.Equal(t, map[string]string{
+		"GET /posts":          "posts",
+		"GET /posts/new":      "new_post",
+		"POST /posts":         "posts",
+		"GET /posts/:id":      "post",
+		"GET /posts/:id/edit": "edit_post",
+		"PATCH /posts/:id":    "post",
+		"PUT /posts/:id":      "post",
+		"DELETE /posts/:id":   "post",
+	}, names)
+}
+
+func TestController_ResourcesOnlyExcept(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	controller := NewController(router, &PostData{})
+	controller.Resources("/posts", postHandlers(), Only(ActionIndex, ActionShow, ActionDestroy), Except(ActionDestroy))
+
+	handlers := postHandlers()
+	handlers.Update = nil
+	controller.Resources("/drafts", handlers, Except(ActionNew, ActionCreate))
+
+	var registered []string
+	for _, route := range router.Routes() {
+		registered = append(registered, route.Method+" "+route.Path)
+	}
+
+	require.Equal(t, []string{
+		"GET /posts",
+		"GET /posts/:id",
+		"GET /drafts",
+		"GET /drafts/:id",
+		"GET /drafts/:id/edit",
+		"DELETE /drafts/:id",
+	}, registered)
+}
+
+func TestController_NestedResources(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	posts := NewController(router, &PostData{}).Resources("/posts", postHandlers(), Only(ActionShow))
+
+	comments := NewController(posts, &PostData{})
+	comments.Resources("/comments", postHandlers(), Only(ActionIndex, ActionNew, ActionShow))
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/posts/1/comments", nil))
+	require.Equal(t, "index map[post_id:1]", res.Body.String())
+
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/posts/1/comments/2", nil))
+	require.Equal(t, "show map[id:2 post_id:1]", res.Body.String())
+
+	path, err := router.Path("post_comments", map[string]string{"post_id": "1"})
+	require.NoError(t, err)
+	require.Equal(t, "/posts/1/comments", path)
+
+	path, err = router.Path("new_post_comment", map[string]string{"post_id": "1"})
+	require.NoError(t, err)
+	require.Equal(t, "/posts/1/comments/new", path)
+
+	path, err = router.Path("post_comment", map[string]string{"post_id": "1", "id": "a b"})
+	require.NoError(t, err)
+	require.Equal(t, "/posts/1/comments/a%20b", path)
+
+	_, err = router.Path("post_comment", map[string]string{"post_id": "1"})
+	require.ErrorContains(t, err, `missing param "id"`)
+
+	_, err = router.Path("missing", nil)
+	require.ErrorContains(t, err, `no route named "missing"`)
+}
+
+func TestController_ResourcesNaming(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	controller := NewController(router, &PostData{})
+	categories := controller.Resources("/categories", postHandlers(), Only(ActionShow))
+	people := controller.Resources("/people", postHandlers(), Only(ActionShow), ResourceName("person"))
+
+	NewController(categories, &PostData{}).Get("/stats", func(ctx context.Context, r *rootRequestContext, p *PostData) {})
+	NewController(people, &PostData{}).Get("/stats", func(ctx context.Context, r *rootRequestContext, p *PostData) {})
+
+	path, err := router.Path("category", map[string]string{"id": "1"})
+	require.NoError(t, err)
+	require.Equal(t, "/categories/1", path)
+
+	path, err = router.Path("person", map[string]string{"id": "1"})
+	require.NoError(t, err)
+	require.Equal(t, "/people/1", path)
+
+	routes := router.Routes()
+	require.Equal(t, "/categories/:category_id/stats", routes[2].Path)
+	require.Equal(t, "/people/:person_id/stats", routes[3].Path)
+}
+
+func TestRouter_NameRoute(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	router.Group("/users").Get("/:id", func(ctx context.Context, r *rootRequestContext) {})
+	router.Group("/users").NameRoute(http.MethodGet, "/:id", "user")
+
+	path, err := router.Path("user", map[string]string{"id": "5"})
+	require.NoError(t, err)
+	require.Equal(t, "/users/5", path)
+
+	require.PanicsWithValue(t, "can not name route \"about\", no route is registered for GET /about", func() {
+		router.NameRoute(http.MethodGet, "/about", "about")
+	})
+
+	router.Get("/profile/:id", func(ctx context.Context, r *rootRequestContext) {})
+	require.PanicsWithValue(t, "route name \"user\" is already used by /users/:id", func() {
+		router.NameRoute(http.MethodGet, "/profile/:id", "user")
+	})
+}
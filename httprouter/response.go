@@ -15,6 +15,8 @@ type Response interface {
 	Flush() (int, error)
 	// Clear resets the buffered response body
 	Clear()
+	// Body returns the buffered response body
+	Body() []byte
 	http.ResponseWriter
 }
 
@@ -77,5 +79,28 @@ func (r *responseWriter) Flush() (int, error) {
 
 // Clear resets the body that would be written to the client
 func (r *responseWriter) Clear() {
-	r.body = []byte{}
+	r.body = r.body[:0]
+}
+
+// Body returns the body that will be written to the client. The returned
+// slice must not be modified.
+func (r *responseWriter) Body() []byte {
+	return r.body
+}
+
+// maxPooledBodySize is the largest buffer that will be retained when a
+// responseWriter is reused so one large response doesn't pin memory.
+const maxPooledBodySize = 64 << 10
+
+// reset prepares the responseWriter to buffer a new response for rw.
+func (r *responseWriter) reset(rw http.ResponseWriter) {
+	r.status = http.StatusOK
+	r.rw = rw
+	r.flushed = false
+
+	if cap(r.body) > maxPooledBodySize {
+		r.body = nil
+	} else {
+		r.body = r.body[:0]
+	}
 }
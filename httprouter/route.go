@@ -1,60 +1,90 @@
 package httprouter
 
 import (
-	"net/http"
+	"net/url"
 	"strings"
 )
 
 type route[T RequestContext] struct {
-	Method  string
-	Path    string
-	parts   []string
-	handler Handler[T]
+	Method     string
+	Path       string
+	Name       string
+	parts      []string
+	paramNames []string
+	handler    Handler[T]
+	// middleware is the middleware stack run before the handler
+	middleware []Middleware[T]
+	// stack returns the middleware of the groups the route was registered
+	// through, which run after middleware
+	stack func() []Middleware[T]
+	// preflight runs the middleware of the route before answering a CORS
+	// preflight request for it
+	preflight Handler[T]
 }
 
-func (r *route[C]) match(req *http.Request) (bool, map[string]string) {
-	if r.Method != req.Method {
-		return false, nil
+// setParams decodes the raw param values captured by the router, in the order
+// they appear in the path, and stores them in params by name.
+func (r *route[T]) setParams(values []string, params map[string]string) {
+	for i, name := range r.paramNames {
+		params[name] = unescapeSegment(values[i])
 	}
-
-	reqParts := normalizeRoutePath(req.URL.Path)
-
-	if len(r.parts) != len(reqParts) {
-		return false, nil
-	}
-
-	params := make(map[string]string)
-
-	for i, part := range r.parts {
-		if strings.HasPrefix(part, ":") {
-			params[part[1:]] = reqParts[i]
-		} else if strings.HasPrefix(part, "*") {
-			params[part[1:]] = strings.Join(reqParts[i:], "/")
-		} else if part != reqParts[i] {
-			return false, nil
-		}
-	}
-
-	return true, params
 }
 
 func (r *route[C]) isWildcard() bool {
-	return r.parts[len(r.parts)-1] == "*"
+	return strings.HasPrefix(r.parts[len(r.parts)-1], "*")
 }
 
 func newRoute[T RequestContext](method string, path string, handler Handler[T]) *route[T] {
+	path = cleanRoutePath(path)
 	parts := normalizeRoutePath(path)
+	paramNames := make([]string, 0)
+	for _, part := range parts {
+		if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+			paramNames = append(paramNames, part[1:])
+		}
+	}
 
-	// TODO better support for `/`, remove double `//`
 	return &route[T]{
-		Method:  method,
-		Path:    path,
-		parts:   parts,
-		handler: handler,
+		Method:     method,
+		Path:       path,
+		parts:      parts,
+		paramNames: paramNames,
+		handler:    handler,
+	}
+}
+
+// cleanRoutePath removes duplicate slashes from a route definition. They're
+// never meaningful and are commonly introduced when joining group prefixes.
+func cleanRoutePath(path string) string {
+	for strings.Contains(path, "//") {
+		path = strings.ReplaceAll(path, "//", "/")
 	}
+
+	return path
 }
 
 func normalizeRoutePath(path string) []string {
 	path = strings.TrimPrefix(path, "/")
 	return strings.Split(path, "/")
 }
+
+// unescapeSegment percent-decodes a single escaped path segment. Segments that
+// can't be decoded are returned as-is.
+func unescapeSegment(segment string) string {
+	if !strings.Contains(segment, "%") {
+		return segment
+	}
+
+	unescaped, err := url.PathUnescape(segment)
+	if err != nil {
+		return segment
+	}
+
+	return unescaped
+}
+
+// foldSegment decodes the segment and lowers its case for case insensitive
+// matching.
+func foldSegment(segment string) string {
+	return strings.ToLower(unescapeSegment(segment))
+}
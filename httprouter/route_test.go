@@ -89,14 +89,61 @@ func TestRouteMatching(t *testing.T) {
 			want:        true,
 			params:      map[string]string{"name": "greg", "location": "boston"},
 		},
+		"encoded slash in param": {
+			reqMethod:   "GET",
+			reqPath:     "/files/docs%2Freadme.md",
+			routeMethod: "GET",
+			routePath:   "/files/:name",
+			want:        true,
+			params:      map[string]string{"name": "docs/readme.md"},
+		},
+		"encoded space in param": {
+			reqMethod:   "GET",
+			reqPath:     "/hello/fox%20mulder",
+			routeMethod: "GET",
+			routePath:   "/hello/:name",
+			want:        true,
+			params:      map[string]string{"name": "fox mulder"},
+		},
+		"multibyte param": {
+			reqMethod:   "GET",
+			reqPath:     "/posts/%E3%81%93%E3%82%93%E3%81%AB%E3%81%A1%E3%81%AF",
+			routeMethod: "GET",
+			routePath:   "/posts/:slug",
+			want:        true,
+			params:      map[string]string{"slug": "こんにちは"},
+		},
+		"multibyte static segment": {
+			reqMethod:   "GET",
+			reqPath:     "/caf%C3%A9",
+			routeMethod: "GET",
+			routePath:   "/café",
+			want:        true,
+			params:      map[string]string{},
+		},
+		"encoded wildcard": {
+			reqMethod:   "GET",
+			reqPath:     "/assets/css/a%20b.css",
+			routeMethod: "GET",
+			routePath:   "/assets/*path",
+			want:        true,
+			params:      map[string]string{"path": "css/a b.css"},
+		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			req := httptest.NewRequest(tc.reqMethod, tc.reqPath, nil)
-			route := newRoute[*rootRequestContext](tc.routeMethod, tc.routePath, func(context.Context, *rootRequestContext) {})
+			router := New(WithBasicRequestContext)
+			router.Match(tc.routeMethod, tc.routePath, func(context.Context, *rootRequestContext) {})
+
+			route, values, got := router.find(req.Method, req.URL.EscapedPath(), nil)
 
-			got, params := route.match(req)
+			var params map[string]string
+			if got {
+				params = make(map[string]string)
+				route.setParams(values, params)
+			}
 
 			require.Equal(t, got, tc.want, "expected route to match")
 			require.Equal(t, params, tc.params, "expected route to match")
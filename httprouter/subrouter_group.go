@@ -11,7 +11,7 @@ import (
 type controllerGroup[T RequestContext, RequestData FromRequest[T]] struct {
 	prefix      string
 	parent      Registerable[T]
-	middlewares []func(context.Context, T, Handler[T])
+	middlewares []Middleware[T]
 }
 
 var _ ControllerRoutable[*rootRequestContext, *placeholderFromRequest] = &Controller[*rootRequestContext, *placeholderFromRequest]{}
@@ -19,38 +19,50 @@ var _ ControllerRoutable[*rootRequestContext, *placeholderFromRequest] = &Contro
 // RawMatch implements the Registerable interface and forwards the call to the
 // parent router. This allows other controllers and controller groups to be
 // registered with the controller.
-func (r *controllerGroup[T, RequestData]) RawMatch(method string, path string, fn Handler[T]) {
-	r.parent.RawMatch(method, joinURL(r.prefix, path), r.wrap(fn))
+func (r *controllerGroup[T, RequestData]) RawMatch(method string, path string, fn Handler[T], middleware ...Middleware[T]) {
+	middleware = append([]Middleware[T](nil), middleware...)
+	r.rawMatchStack(method, path, fn, func() []Middleware[T] {
+		return middleware
+	})
 }
 
-// Match registers the given handler with the given method and path.
-func (r *controllerGroup[T, RequestData]) Match(method string, path string, fn ControllerHandler[T, RequestData]) {
-	r.parent.RawMatch(method, joinURL(r.prefix, path), r.wrap(r.normalizeHandler(fn)))
+// rawMatchStack implements lazyRegisterable and prepends the group middleware
+// to the stack.
+func (r *controllerGroup[T, RequestData]) rawMatchStack(method string, path string, fn Handler[T], stack func() []Middleware[T]) {
+	registerStack(r.parent, method, joinURL(r.prefix, path), fn, func() []Middleware[T] {
+		return appendStack(r.middlewares, stack())
+	})
+}
+
+// Match registers the given handler with the given method and path. The
+// middleware passed only run for this route, before FromRequest is called.
+func (r *controllerGroup[T, RequestData]) Match(method string, path string, fn ControllerHandler[T, RequestData], middleware ...Middleware[T]) {
+	r.RawMatch(method, path, r.normalizeHandler(fn), middleware...)
 }
 
 // Get registers a GET handler with the given path.
-func (r *controllerGroup[T, RequestData]) Get(path string, fn ControllerHandler[T, RequestData]) {
-	r.Match(http.MethodGet, path, fn)
+func (r *controllerGroup[T, RequestData]) Get(path string, fn ControllerHandler[T, RequestData], middleware ...Middleware[T]) {
+	r.Match(http.MethodGet, path, fn, middleware...)
 }
 
 // Post registers a POST handler with the given path.
-func (r *controllerGroup[T, RequestData]) Post(path string, fn ControllerHandler[T, RequestData]) {
-	r.Match(http.MethodPost, path, fn)
+func (r *controllerGroup[T, RequestData]) Post(path string, fn ControllerHandler[T, RequestData], middleware ...Middleware[T]) {
+	r.Match(http.MethodPost, path, fn, middleware...)
 }
 
 // Put registers a PUT handler with the given path.
-func (r *controllerGroup[T, RequestData]) Put(path string, fn ControllerHandler[T, RequestData]) {
-	r.Match(http.MethodPut, path, fn)
+func (r *controllerGroup[T, RequestData]) Put(path string, fn ControllerHandler[T, RequestData], middleware ...Middleware[T]) {
+	r.Match(http.MethodPut, path, fn, middleware...)
 }
 
 // Patch registers a PATCH handler with the given path.
-func (r *controllerGroup[T, RequestData]) Patch(path string, fn ControllerHandler[T, RequestData]) {
-	r.Match(http.MethodPatch, path, fn)
+func (r *controllerGroup[T, RequestData]) Patch(path string, fn ControllerHandler[T, RequestData], middleware ...Middleware[T]) {
+	r.Match(http.MethodPatch, path, fn, middleware...)
 }
 
 // Delete registers a DELETE handler with the given path.
-func (r *controllerGroup[T, RequestData]) Delete(path string, fn ControllerHandler[T, RequestData]) {
-	r.Match(http.MethodDelete, path, fn)
+func (r *controllerGroup[T, RequestData]) Delete(path string, fn ControllerHandler[T, RequestData], middleware ...Middleware[T]) {
+	r.Match(http.MethodDelete, path, fn, middleware...)
 }
 
 // Group returns a new controller group with the given prefix.
@@ -61,25 +73,13 @@ func (r *controllerGroup[T, RequestData]) Group(prefix string) *controllerGroup[
 	}
 }
 
-// Use registers a middleware function that will be called before each handler.
-// Middleware are always called before FromRequest.
+// Use registers a middleware function that will be called before each handler,
+// including handlers that were registered before Use was called. Middleware are
+// always called before FromRequest.
 func (r *controllerGroup[T, RequestData]) Use(fn func(context.Context, T, Handler[T])) {
 	r.middlewares = append(r.middlewares, fn)
 }
 
-func (r *controllerGroup[T, RequestData]) wrap(fn Handler[T]) Handler[T] {
-	handler := fn
-
-	for _, middleware := range r.middlewares {
-		currentHandler := handler
-		handler = func(ctx context.Context, rc T) {
-			middleware(ctx, rc, currentHandler)
-		}
-	}
-
-	return handler
-}
-
 func (r *controllerGroup[T, RequestData]) normalizeHandler(fn ControllerHandler[T, RequestData]) Handler[T] {
 	var t RequestData
 	requestDataType := reflect.TypeOf(t)
@@ -47,7 +47,7 @@ func TestController(t *testing.T) {
 	}
 
 	tests := map[string]struct {
-		routerFn func(string, ControllerHandler[*rootRequestContext, *PostData])
+		routerFn func(string, ControllerHandler[*rootRequestContext, *PostData], ...Middleware[*rootRequestContext])
 		method   string
 	}{
 		"GET":    {method: http.MethodGet, routerFn: controller.Get},
@@ -226,6 +226,27 @@ func Test_ControllerMiddleware(t *testing.T) {
 	)
 }
 
+func Test_ControllerMiddlewareAfterRoute(t *testing.T) {
+	router := New(WithBasicRequestContext)
+	controller := NewController(router, &PostData{})
+
+	var called []string
+	controller.Get("/posts", func(ctx context.Context, r *rootRequestContext, p *PostData) {
+		called = append(called, "handler")
+	})
+	controller.Use(func(ctx context.Context, r *rootRequestContext, next Handler[*rootRequestContext]) {
+		called = append(called, "controller use")
+		next(ctx, r)
+	})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/posts", nil))
+
+	require.Equal(t, http.StatusOK, res.Code)
+	require.Equal(t, []string{"controller use", "handler"}, called)
+	require.Len(t, router.Routes()[0].MiddlewareNames(), 1)
+}
+
 func TestControllerGroup_PrefixRoot(t *testing.T) {
 	router := New(WithBasicRequestContext)
 
@@ -0,0 +1,67 @@
+package httprouter
+
+import "sync"
+
+type (
+	// Values is a per-request store of typed values. It allows middleware to
+	// share data, like the current user or request ID, without knowing the
+	// concrete RequestContext type of the application.
+	//
+	// Values are keyed by their type, so packages should define their own
+	// types for the values they store, e.g. `type RequestID string`.
+	Values struct {
+		mu     sync.RWMutex
+		values map[any]any
+	}
+
+	// valueKey is used as the map key for values of type K. Each instantiation
+	// is a distinct type, so keys never collide.
+	valueKey[K any] struct{}
+)
+
+// Set stores the value in the RequestContext, replacing any existing value of
+// the same type.
+func Set[K any](rc RequestContext, value K) {
+	v := rc.Values()
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.values == nil {
+		v.values = make(map[any]any)
+	}
+
+	v.values[valueKey[K]{}] = value
+}
+
+// Get returns the value of type K stored in the RequestContext. If no value
+// is set, the zero value and false are returned.
+func Get[K any](rc RequestContext) (K, bool) {
+	v := rc.Values()
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	value, ok := v.values[valueKey[K]{}]
+	if !ok {
+		var zero K
+		return zero, false
+	}
+
+	return value.(K), true
+}
+
+// Delete removes the value of type K from the RequestContext.
+func Delete[K any](rc RequestContext) {
+	v := rc.Values()
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	delete(v.values, valueKey[K]{})
+}
+
+// reset removes all values so the store can be reused.
+func (v *Values) reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	clear(v.values)
+}
@@ -0,0 +1,59 @@
+package httprouter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type requestID string
+type currentUser struct {
+	Name string
+}
+
+func TestValues(t *testing.T) {
+	router := New(func(rc RequestContext) *TrackingRequestContext {
+		return &TrackingRequestContext{RequestContext: rc}
+	})
+
+	// Middleware only depend on RequestContext, not the app's concrete type
+	setValues := func(ctx context.Context, rc RequestContext) {
+		Set(rc, requestID("abc123"))
+		Set(rc, &currentUser{Name: "Fox Mulder"})
+	}
+	router.Use(func(ctx context.Context, rc *TrackingRequestContext, next Handler[*TrackingRequestContext]) {
+		_, ok := Get[requestID](rc)
+		require.False(t, ok)
+
+		setValues(ctx, rc)
+		next(ctx, rc)
+	})
+
+	router.Get("/", func(ctx context.Context, rc *TrackingRequestContext) {
+		id, ok := Get[requestID](rc)
+		require.True(t, ok)
+		require.Equal(t, requestID("abc123"), id)
+
+		user, ok := Get[*currentUser](rc)
+		require.True(t, ok)
+		require.Equal(t, "Fox Mulder", user.Name)
+
+		_, ok = Get[string](rc)
+		require.False(t, ok, "values are keyed by type")
+
+		Delete[requestID](rc)
+		_, ok = Get[requestID](rc)
+		require.False(t, ok)
+
+		rc.Response().WriteHeader(http.StatusNoContent)
+	})
+
+	for i := 0; i < 2; i++ {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusNoContent, res.Code)
+	}
+}
@@ -8,6 +8,9 @@ import (
 	"io"
 	"log/slog"
 	"reflect"
+	"time"
+
+	"github.com/blakewilliams/amaro/trace"
 )
 
 var ErrNothingToPop = errors.New("nothing to pop")
@@ -21,6 +24,17 @@ type (
 		jobs       map[string]reflect.Type
 		queueMap   map[reflect.Type]string
 		Logger     *slog.Logger
+		// Tracer, when set, starts a span for each job performed. Jobs
+		// enqueued with a trace in their context are linked to it when
+		// PropagateTrace is enabled.
+		Tracer *trace.Tracer
+		// PropagateTrace wraps the payload of jobs enqueued with a trace in
+		// their context so the trace can be continued by the worker. Workers
+		// always accept wrapped payloads, but older workers decode them as
+		// jobs with zero values, so only enable it once every worker has been
+		// upgraded.
+		PropagateTrace bool
+		metrics        *jobMetrics
 	}
 
 	// Storage is the interface that must be implemented by any storage
@@ -38,6 +52,14 @@ type (
 	Job[T any] interface {
 		PerformJob(T)
 	}
+
+	// ContextJob can be implemented by jobs that need the context they're
+	// performed with. PerformJobContext is called instead of PerformJob, and
+	// the context contains the job's span when JobManager.Tracer is set, so
+	// jobs can start child spans or log the trace ID.
+	ContextJob[T any] interface {
+		PerformJobContext(ctx context.Context, jobContext T)
+	}
 )
 
 // New creates a new background job manager using the given storage. The jobContext passed
@@ -117,6 +139,13 @@ loop:
 }
 
 func (jm *JobManager[T]) processJob(queue string, jobPayload string) {
+	start := time.Now()
+	ctx, encoded := unwrapPayload(jobPayload)
+	ctx, span := jm.startSpan(ctx, queue)
+	if span != nil {
+		defer span.End()
+	}
+
 	t := jm.jobs[queue]
 	// Handle pointers
 	if t.Kind() == reflect.Ptr {
@@ -124,11 +153,15 @@ func (jm *JobManager[T]) processJob(queue string, jobPayload string) {
 	}
 	value := reflect.New(t)
 
-	err := json.Unmarshal([]byte([]byte(jobPayload)), value.Interface())
+	err := json.Unmarshal(encoded, value.Interface())
 	job := value.Interface().(Job[T])
 
 	if err != nil {
 		jm.Logger.Error("failed to decode job JSON", "queue", queue, "error", err, "payload", jobPayload)
+		jm.metrics.observeJob(queue, "invalid", start)
+		if span != nil {
+			span.RecordError(fmt.Errorf("failed to decode job JSON: %w", err))
+		}
 		return
 	}
 
@@ -136,9 +169,18 @@ func (jm *JobManager[T]) processJob(queue string, jobPayload string) {
 		defer func() {
 			if r := recover(); r != nil {
 				jm.Logger.Error("panic in job", "queue", queue, "error", fmt.Sprint(r))
+				jm.metrics.observeJob(queue, "panic", start)
+				if span != nil {
+					span.RecordError(fmt.Errorf("panic in job: %v", r))
+				}
 			}
 		}()
-		job.PerformJob(jm.jobContext)
+		if contextJob, ok := job.(ContextJob[T]); ok {
+			contextJob.PerformJobContext(ctx, jm.jobContext)
+		} else {
+			job.PerformJob(jm.jobContext)
+		}
+		jm.metrics.observeJob(queue, "success", start)
 	}()
 }
 
@@ -167,12 +209,23 @@ func (bm *JobManager[T]) Enqueue(ctx context.Context, job Job[T]) error {
 		return fmt.Errorf("failed to encode job: %v", err)
 	}
 
+	// Jobs enqueued while serving a traced request carry the trace so the
+	// job is linked to the request when it's performed.
+	if bm.PropagateTrace {
+		encoded, err = wrapPayload(ctx, encoded)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = bm.storage.Enqueue(ctx, queueName, string(encoded))
 
 	if err != nil {
 		return fmt.Errorf("failed to push job to storage: %v", err)
 	}
 
+	bm.metrics.observeEnqueue(queueName)
+
 	return nil
 }
 
@@ -115,3 +115,20 @@ func TestProcessQueue(t *testing.T) {
 
 	require.True(t, run)
 }
+
+// processQueued runs every queued job synchronously. Unlike ProcessAll it
+// doesn't race the dequeue loop, so tests can count the jobs that ran.
+func processQueued[T any](t *testing.T, jm *JobManager[T]) {
+	t.Helper()
+
+	for queue := range jm.jobs {
+		for {
+			jsonPayload, err := jm.storage.Dequeue(context.Background(), queue)
+			if err != nil {
+				break
+			}
+
+			jm.processJob(queue, jsonPayload)
+		}
+	}
+}
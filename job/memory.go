@@ -3,9 +3,11 @@ package job
 import (
 	"container/list"
 	"context"
+	"sync"
 )
 
 type MemoryStorage struct {
+	mu     sync.Mutex
 	queues map[string]*list.List
 }
 
@@ -16,6 +18,9 @@ func NewMemoryStorage() *MemoryStorage {
 }
 
 func (ms *MemoryStorage) Enqueue(ctx context.Context, queueName string, payload string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
 	if _, ok := ms.queues[queueName]; !ok {
 		ms.queues[queueName] = list.New()
 	}
@@ -26,6 +31,9 @@ func (ms *MemoryStorage) Enqueue(ctx context.Context, queueName string, payload
 }
 
 func (ms *MemoryStorage) Dequeue(ctx context.Context, queueName string) (string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
 	if _, ok := ms.queues[queueName]; !ok {
 		return "", ErrNothingToPop
 	}
@@ -39,3 +47,17 @@ func (ms *MemoryStorage) Dequeue(ctx context.Context, queueName string) (string,
 
 	return "", ErrNothingToPop
 }
+
+// QueueLength implements QueueLengther and returns the number of jobs in the
+// given queue.
+func (ms *MemoryStorage) QueueLength(ctx context.Context, queueName string) (int64, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	queue, ok := ms.queues[queueName]
+	if !ok {
+		return 0, nil
+	}
+
+	return int64(queue.Len()), nil
+}
@@ -0,0 +1,79 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/blakewilliams/amaro/metrics"
+)
+
+type (
+	// QueueLengther is implemented by Storage that can report the number of
+	// jobs waiting in a queue. It's used to report queue depth metrics.
+	QueueLengther interface {
+		// QueueLength returns the number of jobs in the given queue.
+		QueueLength(ctx context.Context, queueName string) (int64, error)
+	}
+
+	jobMetrics struct {
+		enqueued  *metrics.CounterVec
+		durations *metrics.HistogramVec
+	}
+)
+
+// queueLengthTimeout limits how long reporting the queue depth can take when
+// metrics are written.
+const queueLengthTimeout = 5 * time.Second
+
+// RegisterMetrics records metrics for enqueued and processed jobs in the given
+// registry, which can be shared with the HTTP metrics of the application:
+//
+//	job_enqueued_total{queue}
+//	job_duration_seconds{queue,status}
+//	job_queue_depth{queue}
+//
+// The status is `success`, `panic`, or `invalid` when the job could not be
+// decoded. Queue depth is only reported when the Storage implements
+// QueueLengther.
+func (jm *JobManager[T]) RegisterMetrics(registry *metrics.Registry) {
+	jm.metrics = &jobMetrics{
+		enqueued:  registry.Counter("job_enqueued_total", "Total number of jobs enqueued.", "queue"),
+		durations: registry.Histogram("job_duration_seconds", "Time spent performing jobs.", nil, "queue", "status"),
+	}
+
+	lengther, ok := jm.storage.(QueueLengther)
+	if !ok {
+		return
+	}
+
+	registry.GaugeFunc("job_queue_depth", "Number of jobs waiting in each queue.", []string{"queue"}, func(set func(float64, ...string)) {
+		ctx, cancel := context.WithTimeout(context.Background(), queueLengthTimeout)
+		defer cancel()
+
+		for queue := range jm.jobs {
+			length, err := lengther.QueueLength(ctx, queue)
+			if err != nil {
+				jm.Logger.Error("failed to read queue length", "queue", queue, "error", err)
+				continue
+			}
+
+			set(float64(length), queue)
+		}
+	})
+}
+
+func (m *jobMetrics) observeEnqueue(queue string) {
+	if m == nil {
+		return
+	}
+
+	m.enqueued.Inc(queue)
+}
+
+func (m *jobMetrics) observeJob(queue string, status string, start time.Time) {
+	if m == nil {
+		return
+	}
+
+	m.durations.Observe(time.Since(start).Seconds(), queue, status)
+}
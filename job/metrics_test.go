@@ -0,0 +1,44 @@
+package job
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/blakewilliams/amaro/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+type panicJob struct{}
+
+func (panicJob) PerformJob(string) {
+	panic("omg")
+}
+
+func TestRegisterMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	bm := New(NewMemoryStorage(), jobContext)
+	bm.RegisterQueue("test", fakeJob{})
+	bm.RegisterQueue("panics", panicJob{})
+	bm.RegisterMetrics(registry)
+
+	require.NoError(t, bm.Enqueue(context.Background(), fakeJob{Value: "omg"}))
+	require.NoError(t, bm.Enqueue(context.Background(), fakeJob{Value: "omg"}))
+	require.NoError(t, bm.Enqueue(context.Background(), panicJob{}))
+
+	var b bytes.Buffer
+	_, err := registry.WriteTo(&b)
+	require.NoError(t, err)
+	require.Contains(t, b.String(), `job_enqueued_total{queue="test"} 2`)
+	require.Contains(t, b.String(), `job_queue_depth{queue="test"} 2`)
+	require.Contains(t, b.String(), `job_queue_depth{queue="panics"} 1`)
+
+	processQueued(t, bm)
+
+	b.Reset()
+	_, err = registry.WriteTo(&b)
+	require.NoError(t, err)
+	require.Contains(t, b.String(), `job_queue_depth{queue="test"} 0`)
+	require.Contains(t, b.String(), `job_duration_seconds_count{queue="test",status="success"} 2`)
+	require.Contains(t, b.String(), `job_duration_seconds_count{queue="panics",status="panic"} 1`)
+}
@@ -45,4 +45,16 @@ func (rc *RedisClient) Dequeue(ctx context.Context, queueName string) (string, e
 	return payload, nil
 }
 
+// QueueLength implements QueueLengther and returns the number of jobs in the
+// given queue.
+func (rc *RedisClient) QueueLength(ctx context.Context, queueName string) (int64, error) {
+	length, err := rc.client.LLen(ctx, queueName).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read queue length from redis: %s", err)
+	}
+
+	return length, nil
+}
+
 var _ Storage = (*RedisClient)(nil)
+var _ QueueLengther = (*RedisClient)(nil)
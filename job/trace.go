@@ -0,0 +1,62 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/blakewilliams/amaro/trace"
+)
+
+// tracedPayload wraps the payload of jobs enqueued with a trace in the
+// context so the trace can be continued when the job is performed.
+type tracedPayload struct {
+	Traceparent string          `json:"amaro.traceparent"`
+	Job         json.RawMessage `json:"amaro.job"`
+}
+
+// wrapPayload adds the trace in ctx to the encoded job, if there is one.
+func wrapPayload(ctx context.Context, encoded []byte) ([]byte, error) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return encoded, nil
+	}
+
+	wrapped, err := json.Marshal(tracedPayload{Traceparent: sc.Traceparent(), Job: encoded})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job trace: %v", err)
+	}
+
+	return wrapped, nil
+}
+
+// unwrapPayload returns the encoded job and a context containing the trace it
+// was enqueued with. Payloads enqueued without a trace are returned as is.
+func unwrapPayload(payload string) (context.Context, []byte) {
+	ctx := context.Background()
+
+	var wrapped tracedPayload
+	if err := json.Unmarshal([]byte(payload), &wrapped); err != nil || wrapped.Traceparent == "" || wrapped.Job == nil {
+		return ctx, []byte(payload)
+	}
+
+	sc, err := trace.ParseTraceparent(wrapped.Traceparent)
+	if err != nil {
+		return ctx, wrapped.Job
+	}
+
+	return trace.ContextWithRemoteSpanContext(ctx, sc), wrapped.Job
+}
+
+// startSpan starts a span for performing a job when a Tracer is set and
+// returns a context containing it. The returned span is nil otherwise.
+func (jm *JobManager[T]) startSpan(ctx context.Context, queue string) (context.Context, *trace.Span) {
+	if jm.Tracer == nil {
+		return ctx, nil
+	}
+
+	ctx, span := jm.Tracer.Start(ctx, "job "+queue)
+	span.SetAttribute("job.queue", queue)
+
+	return ctx, span
+}
@@ -0,0 +1,126 @@
+package job
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blakewilliams/amaro/trace"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracing(t *testing.T) {
+	exporter := trace.NewInMemoryExporter()
+	tracer := trace.NewTracer(exporter)
+
+	bm := New(NewMemoryStorage(), jobContext)
+	bm.Tracer = tracer
+	bm.PropagateTrace = true
+	bm.RegisterQueue("test", fakeJob{})
+	bm.RegisterQueue("panics", panicJob{})
+
+	ctx, request := tracer.Start(context.Background(), "GET /")
+	require.NoError(t, bm.Enqueue(ctx, fakeJob{Value: "omg"}))
+	require.NoError(t, bm.Enqueue(ctx, panicJob{}))
+	request.End()
+
+	// Jobs enqueued without a trace start their own
+	require.NoError(t, bm.Enqueue(context.Background(), fakeJob{Value: "omg"}))
+
+	processQueued(t, bm)
+
+	spans := map[string][]*trace.Span{}
+	for _, span := range exporter.Spans()[1:] {
+		spans[span.Name] = append(spans[span.Name], span)
+	}
+
+	require.Len(t, spans["job test"], 2)
+	require.Len(t, spans["job panics"], 1)
+
+	var linked int
+	for _, span := range spans["job test"] {
+		require.Equal(t, "test", span.Attributes["job.queue"])
+		require.Equal(t, trace.StatusUnset, span.Status)
+		if span.Parent == request.SpanContext {
+			require.Equal(t, request.SpanContext.TraceID, span.SpanContext.TraceID)
+			linked++
+		} else {
+			require.False(t, span.Parent.IsValid())
+		}
+	}
+	require.Equal(t, 1, linked)
+
+	panicked := spans["job panics"][0]
+	require.Equal(t, request.SpanContext, panicked.Parent)
+	require.Equal(t, trace.StatusError, panicked.Status)
+}
+
+// tracedJobContext is the job context of childSpanJob.
+type tracedJobContext struct {
+	tracer *trace.Tracer
+}
+
+type childSpanJob struct{}
+
+func (childSpanJob) PerformJob(*tracedJobContext) {
+	panic("PerformJobContext should be called instead")
+}
+
+func (childSpanJob) PerformJobContext(ctx context.Context, jobContext *tracedJobContext) {
+	_, span := jobContext.tracer.Start(ctx, "child")
+	span.End()
+}
+
+func TestTracing_ChildSpan(t *testing.T) {
+	exporter := trace.NewInMemoryExporter()
+	tracer := trace.NewTracer(exporter)
+
+	bm := New(NewMemoryStorage(), &tracedJobContext{tracer: tracer})
+	bm.Tracer = tracer
+	bm.PropagateTrace = true
+	bm.RegisterQueue("child", childSpanJob{})
+
+	ctx, request := tracer.Start(context.Background(), "GET /")
+	require.NoError(t, bm.Enqueue(ctx, childSpanJob{}))
+	request.End()
+
+	processQueued(t, bm)
+
+	spans := map[string]*trace.Span{}
+	for _, span := range exporter.Spans() {
+		spans[span.Name] = span
+	}
+
+	require.Contains(t, spans, "child")
+	require.Equal(t, request.SpanContext.TraceID, spans["child"].SpanContext.TraceID)
+	require.Equal(t, spans["job child"].SpanContext, spans["child"].Parent)
+	require.Equal(t, trace.StatusUnset, spans["job child"].Status)
+}
+
+func TestTracing_WithoutPropagation(t *testing.T) {
+	exporter := trace.NewInMemoryExporter()
+	tracer := trace.NewTracer(exporter)
+
+	storage := NewMemoryStorage()
+	bm := New(storage, jobContext)
+	bm.Tracer = tracer
+	bm.RegisterQueue("test", fakeJob{})
+
+	ctx, request := tracer.Start(context.Background(), "GET /")
+	require.NoError(t, bm.Enqueue(ctx, fakeJob{Value: "omg"}))
+	request.End()
+
+	// The payload is unchanged so workers without trace support can run it
+	payload, err := storage.Dequeue(context.Background(), "test")
+	require.NoError(t, err)
+	require.Equal(t, `{"Value":"omg"}`, payload)
+}
+
+func TestUnwrapPayload(t *testing.T) {
+	ctx, encoded := unwrapPayload(`{"Value":"omg"}`)
+	require.Equal(t, `{"Value":"omg"}`, string(encoded))
+	require.False(t, trace.SpanContextFromContext(ctx).IsValid())
+
+	ctx, encoded = unwrapPayload(`{"amaro.traceparent":"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01","amaro.job":{"Value":"omg"}}`)
+	require.Equal(t, `{"Value":"omg"}`, string(encoded))
+	require.Equal(t, "00f067aa0ba902b7", trace.SpanContextFromContext(ctx).SpanID.String())
+}
@@ -0,0 +1,417 @@
+// package metrics implements a minimal metrics registry that exposes counters,
+// gauges, and histograms in the Prometheus text format without depending on
+// the Prometheus client libraries.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the default histogram buckets, in seconds, suited to
+// measuring request and job durations.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type (
+	// Registry holds the metrics of an application and writes them in the
+	// Prometheus text format. Metrics are registered once by name and
+	// requesting the same metric again returns the existing one, so multiple
+	// components can share a Registry.
+	Registry struct {
+		mu      sync.Mutex
+		metrics map[string]metric
+		order   []string
+	}
+
+	metric interface {
+		describe() *metricDesc
+		write(w *bufio.Writer)
+	}
+
+	metricDesc struct {
+		name       string
+		help       string
+		kind       string
+		labelNames []string
+	}
+
+	// CounterVec is a set of counters partitioned by label values.
+	CounterVec struct {
+		*metricDesc
+		mu     sync.Mutex
+		series map[string]*counterSeries
+	}
+
+	counterSeries struct {
+		labelValues []string
+		value       float64
+	}
+
+	// GaugeVec is a set of gauges partitioned by label values.
+	GaugeVec struct {
+		*metricDesc
+		mu     sync.Mutex
+		series map[string]*counterSeries
+	}
+
+	// gaugeFunc is a gauge whose values are read when metrics are written.
+	gaugeFunc struct {
+		*metricDesc
+		collect func(set func(value float64, labelValues ...string))
+	}
+
+	// HistogramVec is a set of histograms partitioned by label values.
+	HistogramVec struct {
+		*metricDesc
+		buckets []float64
+		mu      sync.Mutex
+		series  map[string]*histogramSeries
+	}
+
+	histogramSeries struct {
+		labelValues []string
+		counts      []uint64
+		count       uint64
+		sum         float64
+	}
+)
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// Counter returns the counter with the given name, registering it if needed.
+func (r *Registry) Counter(name string, help string, labelNames ...string) *CounterVec {
+	return register(r, name, help, "counter", labelNames, func(d *metricDesc) *CounterVec {
+		return &CounterVec{metricDesc: d, series: make(map[string]*counterSeries)}
+	})
+}
+
+// Gauge returns the gauge with the given name, registering it if needed.
+func (r *Registry) Gauge(name string, help string, labelNames ...string) *GaugeVec {
+	return register(r, name, help, "gauge", labelNames, func(d *metricDesc) *GaugeVec {
+		return &GaugeVec{metricDesc: d, series: make(map[string]*counterSeries)}
+	})
+}
+
+// GaugeFunc registers a gauge whose values are collected each time the metrics
+// are written. collect should call set once for each combination of label
+// values, e.g. once per queue when reporting queue depth.
+func (r *Registry) GaugeFunc(name string, help string, labelNames []string, collect func(set func(value float64, labelValues ...string))) {
+	register(r, name, help, "gauge", labelNames, func(d *metricDesc) *gaugeFunc {
+		return &gaugeFunc{metricDesc: d, collect: collect}
+	})
+}
+
+// Histogram returns the histogram with the given name, registering it with the
+// given buckets if needed. DefaultBuckets are used when buckets is nil.
+func (r *Registry) Histogram(name string, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+
+	return register(r, name, help, "histogram", labelNames, func(d *metricDesc) *HistogramVec {
+		sorted := append([]float64(nil), buckets...)
+		sort.Float64s(sorted)
+
+		return &HistogramVec{metricDesc: d, buckets: sorted, series: make(map[string]*histogramSeries)}
+	})
+}
+
+// register returns the existing metric with the given name or registers the
+// metric returned by create. It panics if a metric with the same name but a
+// different type or labels is already registered.
+func register[M metric](r *Registry, name string, help string, kind string, labelNames []string, create func(*metricDesc) M) M {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.metrics[name]; ok {
+		m, ok := existing.(M)
+		if !ok || existing.describe().kind != kind || !equalStrings(existing.describe().labelNames, labelNames) {
+			panic(fmt.Sprintf("metric %q is already registered with a different type or labels", name))
+		}
+
+		return m
+	}
+
+	m := create(&metricDesc{name: name, help: help, kind: kind, labelNames: labelNames})
+	r.metrics[name] = m
+	r.order = append(r.order, name)
+
+	return m
+}
+
+// WriteTo writes all metrics to w in the Prometheus text format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	metrics := make([]metric, len(r.order))
+	for i, name := range r.order {
+		metrics[i] = r.metrics[name]
+	}
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, m := range metrics {
+		d := m.describe()
+		if d.help != "" {
+			fmt.Fprintf(bw, "# HELP %s %s\n", d.name, escapeHelp(d.help))
+		}
+		fmt.Fprintf(bw, "# TYPE %s %s\n", d.name, d.kind)
+		m.write(bw)
+	}
+
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// Handler returns an http.Handler that exposes the metrics, usually mounted at
+// `/metrics`.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = r.WriteTo(w)
+	})
+}
+
+// Inc increments the counter for the given label values by 1.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds value, which must not be negative, to the counter for the given
+// label values.
+func (c *CounterVec) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		panic("counters can not be decreased")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	lookupSeries(c.metricDesc, c.series, labelValues).value += value
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, s := range sortedSeries(c.series) {
+		writeSample(w, c.name, c.labelNames, s.labelValues, "", "", s.value)
+	}
+}
+
+// Set sets the gauge for the given label values.
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	lookupSeries(g.metricDesc, g.series, labelValues).value = value
+}
+
+// Add adds value, which can be negative, to the gauge for the given label
+// values.
+func (g *GaugeVec) Add(value float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	lookupSeries(g.metricDesc, g.series, labelValues).value += value
+}
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, s := range sortedSeries(g.series) {
+		writeSample(w, g.name, g.labelNames, s.labelValues, "", "", s.value)
+	}
+}
+
+func (g *gaugeFunc) write(w *bufio.Writer) {
+	series := make(map[string]*counterSeries)
+	g.collect(func(value float64, labelValues ...string) {
+		lookupSeries(g.metricDesc, series, labelValues).value = value
+	})
+
+	for _, s := range sortedSeries(series) {
+		writeSample(w, g.name, g.labelNames, s.labelValues, "", "", s.value)
+	}
+}
+
+// Observe records value in the histogram for the given label values.
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := seriesKey(h.metricDesc, labelValues)
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{
+			labelValues: append([]string(nil), labelValues...),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = s
+	}
+
+	for i, bucket := range h.buckets {
+		if value <= bucket {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += value
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := h.series[key]
+		for i, bucket := range h.buckets {
+			writeSample(w, h.name+"_bucket", h.labelNames, s.labelValues, "le", formatFloat(bucket), float64(s.counts[i]))
+		}
+		writeSample(w, h.name+"_bucket", h.labelNames, s.labelValues, "le", "+Inf", float64(s.count))
+		writeSample(w, h.name+"_sum", h.labelNames, s.labelValues, "", "", s.sum)
+		writeSample(w, h.name+"_count", h.labelNames, s.labelValues, "", "", float64(s.count))
+	}
+}
+
+func (d *metricDesc) describe() *metricDesc {
+	return d
+}
+
+// seriesKey returns the map key for the given label values, panicking if the
+// number of values doesn't match the label names.
+func seriesKey(d *metricDesc, labelValues []string) string {
+	if len(labelValues) != len(d.labelNames) {
+		panic(fmt.Sprintf("metric %q expects %d label values, got %d", d.name, len(d.labelNames), len(labelValues)))
+	}
+
+	return strings.Join(labelValues, "\xff")
+}
+
+func lookupSeries(d *metricDesc, series map[string]*counterSeries, labelValues []string) *counterSeries {
+	key := seriesKey(d, labelValues)
+	s, ok := series[key]
+	if !ok {
+		s = &counterSeries{labelValues: append([]string(nil), labelValues...)}
+		series[key] = s
+	}
+
+	return s
+}
+
+func sortedSeries(series map[string]*counterSeries) []*counterSeries {
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sorted := make([]*counterSeries, len(keys))
+	for i, key := range keys {
+		sorted[i] = series[key]
+	}
+
+	return sorted
+}
+
+// writeSample writes a single sample line. extraName and extraValue are used
+// for the `le` label of histogram buckets.
+func writeSample(w *bufio.Writer, name string, labelNames []string, labelValues []string, extraName string, extraValue string, value float64) {
+	w.WriteString(name)
+
+	if len(labelNames) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, labelName := range labelNames {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(labelName)
+			w.WriteString(`="`)
+			w.WriteString(escapeLabelValue(labelValues[i]))
+			w.WriteByte('"')
+		}
+
+		if extraName != "" {
+			if len(labelNames) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extraName)
+			w.WriteString(`="`)
+			w.WriteString(extraValue)
+			w.WriteByte('"')
+		}
+		w.WriteByte('}')
+	}
+
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueReplacer.Replace(value)
+}
+
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(help string) string {
+	return helpReplacer.Replace(help)
+}
+
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+
+	return n, err
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+
+	counter := registry.Counter("jobs_total", "Jobs run.", "queue")
+	counter.Inc("mailers")
+	counter.Add(2, "mailers")
+	counter.Inc(`we"ird\`)
+
+	// Requesting an existing metric returns it
+	require.Same(t, counter, registry.Counter("jobs_total", "Jobs run.", "queue"))
+
+	gauge := registry.Gauge("temperature", "")
+	gauge.Set(10)
+	gauge.Add(-2.5)
+
+	registry.GaugeFunc("queue_depth", "Jobs waiting.", []string{"queue"}, func(set func(float64, ...string)) {
+		set(3, "mailers")
+		set(0, "default")
+	})
+
+	histogram := registry.Histogram("duration_seconds", "Durations.", []float64{1, 0.1}, "queue")
+	histogram.Observe(0.05, "mailers")
+	histogram.Observe(0.5, "mailers")
+	histogram.Observe(5, "mailers")
+
+	var b bytes.Buffer
+	n, err := registry.WriteTo(&b)
+	require.NoError(t, err)
+	require.Equal(t, int64(b.Len()), n)
+
+	expected := `# HELP jobs_total Jobs run.
+# TYPE jobs_total counter
+jobs_total{queue="mailers"} 3
+jobs_total{queue="we\"ird\\"} 1
+# TYPE temperature gauge
+temperature 7.5
+# HELP queue_depth Jobs waiting.
+# TYPE queue_depth gauge
+queue_depth{queue="default"} 0
+queue_depth{queue="mailers"} 3
+# HELP duration_seconds Durations.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{queue="mailers",le="0.1"} 1
+duration_seconds_bucket{queue="mailers",le="1"} 2
+duration_seconds_bucket{queue="mailers",le="+Inf"} 3
+duration_seconds_sum{queue="mailers"} 5.55
+duration_seconds_count{queue="mailers"} 3
+`
+	require.Equal(t, expected, b.String())
+}
+
+func TestRegistry_Conflicts(t *testing.T) {
+	registry := NewRegistry()
+	registry.Counter("requests_total", "", "method")
+
+	require.Panics(t, func() { registry.Gauge("requests_total", "", "method") })
+	require.Panics(t, func() { registry.Counter("requests_total", "", "status") })
+	require.Panics(t, func() { registry.Counter("requests_total", "", "method").Inc() })
+	require.Panics(t, func() { registry.Counter("requests_total", "", "method").Add(-1, "GET") })
+}
@@ -0,0 +1,304 @@
+// package trace implements minimal distributed tracing compatible with
+// OpenTelemetry. Trace and span IDs follow the W3C Trace Context format so
+// traces can be continued across services, and spans are handed to an
+// Exporter when they end so they can be forwarded to an OpenTelemetry SDK or
+// collector.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceparentHeader is the W3C Trace Context header used to propagate traces.
+const TraceparentHeader = "traceparent"
+
+// ErrInvalidTraceparent is returned when a traceparent header can't be parsed.
+var ErrInvalidTraceparent = errors.New("invalid traceparent")
+
+type (
+	// TraceID identifies a trace.
+	TraceID [16]byte
+
+	// SpanID identifies a span within a trace.
+	SpanID [8]byte
+
+	// SpanContext identifies a span and is propagated between services and
+	// jobs.
+	SpanContext struct {
+		TraceID TraceID
+		SpanID  SpanID
+		// Sampled reports whether the trace is recorded and exported.
+		Sampled bool
+	}
+
+	// StatusCode is the status of a span.
+	StatusCode int
+
+	// Exporter receives spans when they end. Implementations must be safe
+	// for concurrent use.
+	Exporter interface {
+		ExportSpan(span *Span)
+	}
+
+	// Tracer starts spans and exports them when they end.
+	Tracer struct {
+		exporter Exporter
+	}
+
+	// Span represents a unit of work, like serving a request or performing a
+	// job. The exported fields must not be modified, use the methods instead.
+	Span struct {
+		Name        string
+		SpanContext SpanContext
+		// Parent is the span that started this span. It is invalid for root
+		// spans.
+		Parent        SpanContext
+		StartTime     time.Time
+		EndTime       time.Time
+		Attributes    map[string]any
+		Status        StatusCode
+		StatusMessage string
+		Errors        []error
+
+		mu     sync.Mutex
+		tracer *Tracer
+		ended  bool
+	}
+
+	spanKey       struct{}
+	remoteSpanKey struct{}
+)
+
+const (
+	// StatusUnset is the default status of a span.
+	StatusUnset StatusCode = iota
+	// StatusOK indicates the work completed successfully.
+	StatusOK
+	// StatusError indicates the work failed.
+	StatusError
+)
+
+// String returns the name of the status.
+func (s StatusCode) String() string {
+	switch s {
+	case StatusOK:
+		return "ok"
+	case StatusError:
+		return "error"
+	default:
+		return "unset"
+	}
+}
+
+// NewTracer returns a Tracer that passes ended spans to exporter.
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+// Start starts a span as a child of the span in ctx, or of a remote span
+// context added via ContextWithRemoteSpanContext. A new trace is started when
+// ctx contains neither. The returned context contains the new span.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	parent := SpanContextFromContext(ctx)
+
+	spanContext := SpanContext{TraceID: parent.TraceID, SpanID: newSpanID(), Sampled: true}
+	if parent.IsValid() {
+		spanContext.Sampled = parent.Sampled
+	} else {
+		spanContext.TraceID = newTraceID()
+	}
+
+	span := &Span{
+		Name:        name,
+		SpanContext: spanContext,
+		Parent:      parent,
+		StartTime:   time.Now(),
+		Attributes:  make(map[string]any),
+		tracer:      t,
+	}
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// SetAttribute sets an attribute on the span.
+func (s *Span) SetAttribute(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.ended {
+		s.Attributes[key] = value
+	}
+}
+
+// SetStatus sets the status of the span. An error status is not replaced by a
+// later status.
+func (s *Span) SetStatus(code StatusCode, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended || s.Status == StatusError {
+		return
+	}
+
+	s.Status = code
+	s.StatusMessage = message
+}
+
+// RecordError records err on the span and sets its status to StatusError.
+func (s *Span) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return
+	}
+
+	s.Errors = append(s.Errors, err)
+	if s.Status != StatusError {
+		s.Status = StatusError
+		s.StatusMessage = err.Error()
+	}
+}
+
+// End ends the span and exports it when the trace is sampled. Calling End more
+// than once has no effect.
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+
+	s.ended = true
+	s.EndTime = time.Now()
+	s.mu.Unlock()
+
+	if s.SpanContext.Sampled && s.tracer.exporter != nil {
+		s.tracer.exporter.ExportSpan(s)
+	}
+}
+
+// Duration returns how long the span took.
+func (s *Span) Duration() time.Duration {
+	return s.EndTime.Sub(s.StartTime)
+}
+
+// SpanFromContext returns the span in ctx, or nil if there is none.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// SpanContextFromContext returns the SpanContext of the span in ctx, falling
+// back to the remote span context added via ContextWithRemoteSpanContext.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext
+	}
+
+	remote, _ := ctx.Value(remoteSpanKey{}).(SpanContext)
+	return remote
+}
+
+// ContextWithRemoteSpanContext returns a context that starts spans as children
+// of a span from another process, like the caller of a request.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteSpanKey{}, sc)
+}
+
+// Extract returns a context containing the span context of the traceparent
+// header, if it's present and valid.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	sc, err := ParseTraceparent(header.Get(TraceparentHeader))
+	if err != nil {
+		return ctx
+	}
+
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// Inject sets the traceparent header to the span context in ctx so that
+// outgoing requests continue the trace.
+func Inject(ctx context.Context, header http.Header) {
+	if sc := SpanContextFromContext(ctx); sc.IsValid() {
+		header.Set(TraceparentHeader, sc.Traceparent())
+	}
+}
+
+// IsValid reports whether the span context has a trace and span ID.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// Traceparent returns the span context in the W3C traceparent format, e.g.
+// `00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01`.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent parses a W3C traceparent header.
+func ParseTraceparent(traceparent string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, fmt.Errorf("%w: %q", ErrInvalidTraceparent, traceparent)
+	}
+
+	// Version 00 has exactly four fields, future versions may add more.
+	if parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, fmt.Errorf("%w: %q", ErrInvalidTraceparent, traceparent)
+	}
+
+	var sc SpanContext
+	var flags [1]byte
+	_, err1 := hex.Decode(sc.TraceID[:], []byte(parts[1]))
+	_, err2 := hex.Decode(sc.SpanID[:], []byte(parts[2]))
+	_, err3 := hex.Decode(flags[:], []byte(parts[3]))
+	if err := errors.Join(err1, err2, err3); err != nil || !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("%w: %q", ErrInvalidTraceparent, traceparent)
+	}
+
+	sc.Sampled = flags[0]&1 == 1
+
+	return sc, nil
+}
+
+// String returns the hex encoded trace ID.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// String returns the hex encoded span ID.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	if _, err := rand.Read(id[:]); err != nil {
+		panic(err)
+	}
+
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	if _, err := rand.Read(id[:]); err != nil {
+		panic(err)
+	}
+
+	return id
+}
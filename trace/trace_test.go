@@ -0,0 +1,123 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	require.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	require.True(t, sc.Sampled)
+	require.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sc.Traceparent())
+
+	sc, err = ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	require.NoError(t, err)
+	require.False(t, sc.Sampled)
+
+	// Future versions may add fields
+	_, err = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+	require.NoError(t, err)
+
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01",
+	}
+
+	for _, traceparent := range invalid {
+		_, err := ParseTraceparent(traceparent)
+		require.ErrorIs(t, err, ErrInvalidTraceparent, traceparent)
+	}
+}
+
+func TestTracer_Start(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	tracer := NewTracer(exporter)
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	require.True(t, root.SpanContext.IsValid())
+	require.False(t, root.Parent.IsValid())
+	require.True(t, root.SpanContext.Sampled)
+	require.Equal(t, root, SpanFromContext(ctx))
+
+	_, child := tracer.Start(ctx, "child")
+	require.Equal(t, root.SpanContext.TraceID, child.SpanContext.TraceID)
+	require.Equal(t, root.SpanContext, child.Parent)
+	require.NotEqual(t, root.SpanContext.SpanID, child.SpanContext.SpanID)
+
+	child.End()
+	root.End()
+	root.End()
+
+	spans := exporter.Spans()
+	require.Len(t, spans, 2)
+	require.Equal(t, "child", spans[0].Name)
+	require.Equal(t, "root", spans[1].Name)
+	require.False(t, root.EndTime.IsZero())
+
+	exporter.Reset()
+	require.Empty(t, exporter.Spans())
+}
+
+func TestTracer_StartRemote(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	tracer := NewTracer(exporter)
+
+	remote, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	require.NoError(t, err)
+
+	_, span := tracer.Start(ContextWithRemoteSpanContext(context.Background(), remote), "remote")
+	require.Equal(t, remote.TraceID, span.SpanContext.TraceID)
+	require.Equal(t, remote, span.Parent)
+	require.False(t, span.SpanContext.Sampled)
+
+	span.End()
+	require.Empty(t, exporter.Spans())
+}
+
+func TestSpan_Status(t *testing.T) {
+	tracer := NewTracer(NewInMemoryExporter())
+	_, span := tracer.Start(context.Background(), "test")
+
+	span.SetStatus(StatusOK, "")
+	require.Equal(t, StatusOK, span.Status)
+
+	span.RecordError(errors.New("oops"))
+	span.SetStatus(StatusOK, "")
+	require.Equal(t, StatusError, span.Status)
+	require.Equal(t, "oops", span.StatusMessage)
+	require.Len(t, span.Errors, 1)
+
+	span.SetAttribute("key", "value")
+	span.End()
+	span.SetAttribute("ignored", true)
+	require.Equal(t, map[string]any{"key": "value"}, span.Attributes)
+}
+
+func TestExtractInject(t *testing.T) {
+	header := http.Header{}
+	header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	ctx := Extract(context.Background(), header)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", SpanContextFromContext(ctx).TraceID.String())
+
+	ctx, span := NewTracer(nil).Start(ctx, "test")
+	out := http.Header{}
+	Inject(ctx, out)
+	require.Equal(t, span.SpanContext.Traceparent(), out.Get(TraceparentHeader))
+
+	out = http.Header{}
+	Inject(Extract(context.Background(), http.Header{}), out)
+	require.Empty(t, out.Get(TraceparentHeader))
+}